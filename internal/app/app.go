@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/wb-go/wbf/zlog"
 	"github.com/yokitheyo/CommentTree/internal/config"
@@ -12,6 +13,8 @@ type App struct {
 	lg   *zlog.Zerolog
 	deps *dependencies
 	rm   *resourceManager
+
+	workers sync.WaitGroup
 }
 
 func New(configPath string) (*App, error) {
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -9,19 +10,35 @@ import (
 	"github.com/wb-go/wbf/retry"
 	"github.com/wb-go/wbf/zlog"
 	"github.com/yokitheyo/CommentTree/internal/config"
+	"github.com/yokitheyo/CommentTree/internal/domain"
 	"github.com/yokitheyo/CommentTree/internal/handler/http"
 	"github.com/yokitheyo/CommentTree/internal/handler/middleware"
 	infradatabase "github.com/yokitheyo/CommentTree/internal/infrastructure/database"
+	"github.com/yokitheyo/CommentTree/internal/infrastructure/eventbus"
+	"github.com/yokitheyo/CommentTree/internal/infrastructure/outbox"
+	"github.com/yokitheyo/CommentTree/internal/infrastructure/ratelimit"
 	"github.com/yokitheyo/CommentTree/internal/infrastructure/search"
+	"github.com/yokitheyo/CommentTree/internal/infrastructure/stream"
+	"github.com/yokitheyo/CommentTree/internal/infrastructure/webhook"
+	"github.com/yokitheyo/CommentTree/internal/pkg/jwt"
 	"github.com/yokitheyo/CommentTree/internal/repository/postgres"
 	retrypkg "github.com/yokitheyo/CommentTree/internal/retry"
 	"github.com/yokitheyo/CommentTree/internal/usecase"
 )
 
 type dependencies struct {
-	database *dbpg.DB
-	engine   *ginext.Engine
-	usecase  *usecase.CommentUsecase
+	database   *dbpg.DB
+	engine     *ginext.Engine
+	comments   domain.CommentRepository
+	usecase    *usecase.CommentUsecase
+	moderation *usecase.ModerationUsecase
+	notices    *usecase.NotificationUsecase
+	events     domain.EventBus
+	stream     *stream.Broadcaster
+	webhooks   *usecase.WebhookUsecase
+	dispatcher *webhook.Dispatcher
+	relay      *outbox.Relay
+	backfill   *search.Backfill
 }
 
 type dependencyBuilder struct {
@@ -101,28 +118,249 @@ func (b *dependencyBuilder) initRepository() error {
 	b.lg.Info().Msg("initializing repository")
 
 	repo := postgres.NewCommentRepository(b.deps.database, retrypkg.DefaultStrategy)
-	fts := search.NewPostgresFullText(repo)
+	b.deps.comments = repo
+	tx := postgres.NewTransactor(b.deps.database)
+	notifications := postgres.NewNotificationRepository(b.deps.database, retrypkg.DefaultStrategy)
+	fts := search.NewPostgresFullText(repo, b.cfg.Search.HighlightStart, b.cfg.Search.HighlightStop)
+	b.deps.backfill = search.NewBackfill(postgres.NewSearchIndexRepository(b.deps.database),
+		b.cfg.Search.BackfillBatchSize, time.Duration(b.cfg.Search.BackfillPauseMS)*time.Millisecond)
+
+	bus, err := b.newEventBus(repo)
+	if err != nil {
+		return fmt.Errorf("initializing event bus: %w", err)
+	}
+	b.deps.events = bus
+	// Изменения комментариев попадают в шину только через outbox — в той же транзакции, что и запись
+	b.deps.relay = outbox.NewRelay(postgres.NewOutboxRepository(b.deps.database), bus, outbox.Options{
+		BatchSize:    b.cfg.Outbox.BatchSize,
+		PollInterval: time.Duration(b.cfg.Outbox.PollIntervalMS) * time.Millisecond,
+		Retention:    time.Duration(b.cfg.Outbox.RetentionHours) * time.Hour,
+	})
+	// Каждый экземпляр раздаёт своим SSE-клиентам все события шины, а не только свои
+	b.deps.stream = stream.NewBroadcaster(b.cfg.Stream.BufferSize)
+	bus.Subscribe(b.deps.stream.Publish)
+
+	hooks := postgres.NewWebhookRepository(b.deps.database, retrypkg.DefaultStrategy)
+	b.deps.webhooks = usecase.NewWebhookUsecase(hooks)
+	if wh := b.cfg.Webhooks; wh.Enabled {
+		// Доставки ставятся в очередь в транзакции разбора outbox, а не из шины: NOTIFY может потеряться
+		b.deps.relay.AddSink(b.deps.webhooks.EnqueueEvent)
+		b.deps.dispatcher = webhook.NewDispatcher(hooks, webhook.Options{
+			Backoff: retry.Strategy{
+				Attempts: wh.MaxAttempts,
+				Delay:    time.Duration(wh.BackoffInitialSec) * time.Second,
+				Backoff:  wh.BackoffFactor,
+			},
+			BatchSize:    wh.BatchSize,
+			PollInterval: time.Duration(wh.PollIntervalMS) * time.Millisecond,
+			Timeout:      time.Duration(wh.TimeoutSec) * time.Second,
+		})
+	}
+
+	spam, err := b.newSpamFilter()
+	if err != nil {
+		return fmt.Errorf("initializing spam filter: %w", err)
+	}
 
-	b.deps.usecase = usecase.NewCommentUsecase(repo, fts)
+	v := b.cfg.Validation
+	validator, err := usecase.NewValidator(usecase.ValidationRules{
+		MaxContentLength:   v.MaxContentLength,
+		MaxAuthorLength:    v.MaxAuthorLength,
+		MaxThreadKeyLength: v.MaxThreadKeyLength,
+		ContentCategories:  v.ContentCategories,
+		AuthorCategories:   v.AuthorCategories,
+		MaxDepth:           v.MaxDepth,
+	})
+	if err != nil {
+		return fmt.Errorf("initializing validation: %w", err)
+	}
+
+	opts := []usecase.Option{
+		usecase.WithPremoderation(b.cfg.Moderation.Premoderation, b.cfg.Moderation.PremoderatedThreads),
+		usecase.WithRequireAuth(b.cfg.Auth.Enabled && !b.cfg.Auth.AllowAnonymous),
+		usecase.WithManagementTokens(time.Duration(b.cfg.Comments.ManagementTokenWindowSec) * time.Second),
+		usecase.WithPermalinkDepth(b.cfg.Comments.PermalinkDepth, b.cfg.Comments.MaxPermalinkDepth),
+		usecase.WithNotifications(notifications),
+		usecase.WithTransactor(tx),
+		usecase.WithEventStream(b.deps.stream),
+		usecase.WithValidator(validator),
+	}
+	if spam != nil {
+		opts = append(opts, usecase.WithSpamFilter(spam))
+	}
+
+	b.deps.usecase = usecase.NewCommentUsecase(repo, fts, opts...)
+	b.deps.moderation = usecase.NewModerationUsecase(repo, tx, spam, notifications)
+	b.deps.notices = usecase.NewNotificationUsecase(notifications)
+
+	if spam != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := b.deps.moderation.TrainSpamFilter(ctx); err != nil {
+			// Без обучения работают остальные классификаторы; байесовский подождёт ручного запуска
+			b.lg.Warn().Err(err).Msg("initial spam filter training failed")
+		}
+	}
 
 	b.lg.Info().Msg("repository and usecase initialized")
 	return nil
 }
 
+// newSpamFilter собирает цепочку классификаторов из конфига; nil — фильтр выключен.
+func (b *dependencyBuilder) newSpamFilter() (*usecase.SpamFilter, error) {
+	cfg := b.cfg.Spam
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	blocklist, err := usecase.NewBlocklistClassifier(cfg.Blocklist, cfg.BlocklistPatterns, cfg.BlocklistWeight)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.NewSpamFilter(cfg.PendingThreshold, cfg.RejectThreshold,
+		usecase.NewLinkClassifier(cfg.MaxLinks, cfg.LinkWeight),
+		blocklist,
+		usecase.NewRepeatedCharClassifier(cfg.MaxRepeatedChars, cfg.RepeatedWeight),
+		usecase.NewNaiveBayesClassifier(cfg.BayesWeight, cfg.BayesMinSamples),
+	), nil
+}
+
+func (b *dependencyBuilder) newEventBus(repo domain.CommentRepository) (domain.EventBus, error) {
+	switch b.cfg.Events.Bus {
+	case "", "memory":
+		return eventbus.NewMemoryBus(), nil
+	case "postgres":
+		bus, err := eventbus.NewPostgresBus(b.deps.database, b.cfg.Database.DSN, b.cfg.Events.Channel, repo.FindByID)
+		if err != nil {
+			return nil, err
+		}
+		b.rm.addResource(resource{name: "event bus", closeFunc: bus.Close})
+		return bus, nil
+	default:
+		return nil, fmt.Errorf("unknown event bus %q", b.cfg.Events.Bus)
+	}
+}
+
+func (b *dependencyBuilder) newTokenVerifier() (*jwt.Verifier, error) {
+	cfg := b.cfg.Auth
+	keys := jwt.NewKeySet()
+
+	if cfg.HS256Secret != "" {
+		keys.AddHMAC("", []byte(cfg.HS256Secret))
+	}
+	for _, path := range cfg.RS256PublicKeyFiles {
+		if err := keys.AddRSAPEMFile("", path); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.JWKSFile != "" {
+		if err := keys.LoadJWKSFile(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+	if keys.Empty() {
+		return nil, fmt.Errorf("auth enabled but no verification keys configured")
+	}
+
+	return jwt.NewVerifier(keys, jwt.Options{
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   time.Duration(cfg.LeewaySec) * time.Second,
+	}), nil
+}
+
+func (b *dependencyBuilder) newRateLimitStore() (ratelimit.Store, error) {
+	switch b.cfg.RateLimit.Store {
+	case "", "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "postgres":
+		return ratelimit.NewPostgresStore(b.deps.database, time.Duration(b.cfg.RateLimit.BucketTTL)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", b.cfg.RateLimit.Store)
+	}
+}
+
+// threadOf возвращает обсуждение комментария для лимита записи
+func (b *dependencyBuilder) threadOf(ctx context.Context, commentID int64) (string, error) {
+	c, err := b.deps.comments.FindByID(ctx, commentID)
+	if err != nil {
+		return "", err
+	}
+	return c.ThreadKey, nil
+}
+
 func (b *dependencyBuilder) initEngine() error {
 	b.lg.Info().Msg("initializing Gin engine")
 
 	engine := ginext.New("")
-	engine.Use(middleware.LoggerMiddleware(), middleware.CORSMiddleware())
+	// Обработчики передают *gin.Context как context.Context; без fallback значения
+	// из контекста запроса (читатель и т.п.) до usecase не доходят
+	engine.ContextWithFallback = true
+	// По умолчанию gin верит X-Forwarded-For от кого угодно, и лимиты по IP обходятся подделкой заголовка
+	if err := engine.SetTrustedProxies(b.cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("setting trusted proxies: %w", err)
+	}
+	// ErrorMiddleware — сразу за логгером: он пишет ответы на ошибки всех следующих звеньев
+	engine.Use(middleware.LoggerMiddleware(), middleware.ErrorMiddleware(), middleware.CORSMiddleware())
+	if limit := b.cfg.Validation.MaxBodyBytes; limit > 0 {
+		engine.Use(middleware.BodyLimitMiddleware(limit))
+	}
+
+	if b.cfg.Auth.Enabled {
+		verifier, err := b.newTokenVerifier()
+		if err != nil {
+			return fmt.Errorf("initializing auth: %w", err)
+		}
+		engine.Use(middleware.AuthMiddleware(verifier, middleware.ClaimNames{
+			Name:    b.cfg.Auth.NameClaim,
+			Roles:   b.cfg.Auth.RolesClaim,
+			Threads: b.cfg.Auth.ThreadsClaim,
+		}))
+	}
+	engine.Use(middleware.ViewerMiddleware(middleware.ViewerKeys{
+		Moderator: b.cfg.Moderation.ModeratorKey,
+		Admin:     b.cfg.Moderation.AdminKey,
+	}))
+
+	if rl := b.cfg.RateLimit; rl.Enabled {
+		store, err := b.newRateLimitStore()
+		if err != nil {
+			return fmt.Errorf("initializing rate limiter: %w", err)
+		}
+		engine.Use(middleware.RateLimitMiddleware(store, middleware.RateLimits{
+			IP:     ratelimit.Limit(rl.IP),
+			Author: ratelimit.Limit(rl.Author),
+			Thread: ratelimit.Limit(rl.Thread),
+		}, b.threadOf))
+	}
 
 	engine.GET("/", func(c *ginext.Context) {
 		c.File("./static/index.html")
 	})
 	engine.Static("/static", "./static")
 
-	handler := http.NewCommentHandler(b.deps.usecase)
+	pages := http.PageLimits{
+		DefaultLimit: b.cfg.Validation.DefaultLimit,
+		MaxLimit:     b.cfg.Validation.MaxLimit,
+		MaxOffset:    b.cfg.Validation.MaxOffset,
+	}
+
+	handler := http.NewCommentHandler(b.deps.usecase, pages)
 	handler.RegisterRoutes(engine)
 
+	moderationHandler := http.NewModerationHandler(b.deps.moderation, pages)
+	moderationHandler.RegisterRoutes(engine)
+
+	notificationHandler := http.NewNotificationHandler(b.deps.notices, pages)
+	notificationHandler.RegisterRoutes(engine)
+
+	streamHandler := http.NewStreamHandler(b.deps.usecase, time.Duration(b.cfg.Stream.HeartbeatSec)*time.Second)
+	streamHandler.RegisterRoutes(engine)
+
+	webhookHandler := http.NewWebhookHandler(b.deps.webhooks, pages)
+	webhookHandler.RegisterRoutes(engine)
+
 	b.deps.engine = engine
 
 	b.lg.Info().Msg("Gin engine initialized")
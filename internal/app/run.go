@@ -8,9 +8,12 @@ import (
 )
 
 func (a *App) Run(ctx context.Context) error {
-	srv := &http.Server{
-		Addr:    a.cfg.Server.Addr,
-		Handler: a.deps.engine,
+	srv := a.newServer()
+
+	a.startWorker(ctx, a.deps.relay.Run)
+	a.startWorker(ctx, a.deps.backfill.Run)
+	if a.deps.dispatcher != nil {
+		a.startWorker(ctx, a.deps.dispatcher.Run)
 	}
 
 	go func() {
@@ -40,6 +43,9 @@ func (a *App) Shutdown(parentCtx context.Context, srv *http.Server) error {
 
 	a.lg.Info().Msg("HTTP server stopped gracefully")
 
+	// Фоновые воркеры дописывают результаты в базу — закрывать её можно только после них
+	a.workers.Wait()
+
 	if err := a.Close(); err != nil {
 		a.lg.Error().Err(err).Msg("failed to close resources")
 		return fmt.Errorf("close app resources: %w", err)
@@ -48,3 +54,24 @@ func (a *App) Shutdown(parentCtx context.Context, srv *http.Server) error {
 	a.lg.Info().Msg("shutdown complete")
 	return nil
 }
+
+func (a *App) newServer() *http.Server {
+	srv := &http.Server{
+		Addr:    a.cfg.Server.Addr,
+		Handler: a.deps.engine,
+	}
+	// Shutdown не отменяет контексты запросов, а SSE-лента без этого висела бы до таймаута
+	if a.deps.stream != nil {
+		srv.RegisterOnShutdown(a.deps.stream.Close)
+	}
+	return srv
+}
+
+// startWorker запускает фоновый воркер; Shutdown дожидается его завершения
+func (a *App) startWorker(ctx context.Context, run func(context.Context)) {
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		run(ctx)
+	}()
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/config"
+	handler "github.com/yokitheyo/CommentTree/internal/handler/http"
+	"github.com/yokitheyo/CommentTree/internal/infrastructure/stream"
+	"github.com/yokitheyo/CommentTree/internal/usecase"
+)
+
+func TestShutdownWithOpenStream(t *testing.T) {
+	broadcaster := stream.NewBroadcaster(16)
+	engine := ginext.New("")
+	engine.ContextWithFallback = true
+	comments := usecase.NewCommentUsecase(nil, nil, usecase.WithEventStream(broadcaster))
+	handler.NewStreamHandler(comments, time.Minute).RegisterRoutes(engine)
+
+	lg := zlog.Logger
+	cfg := &config.Config{}
+	cfg.Server.ShutdownTimeoutSec = 10
+	a := &App{
+		cfg:  cfg,
+		lg:   &lg,
+		deps: &dependencies{engine: engine, stream: broadcaster},
+		rm:   &resourceManager{},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := a.newServer()
+	go func() { _ = srv.Serve(ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/comments/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// Первая строка ответа означает, что обработчик уже ждёт событий
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("stream preamble = %q, %v", line, err)
+	}
+
+	start := time.Now()
+	if err := a.Shutdown(context.Background(), srv); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("Shutdown took %v with an open stream", elapsed)
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("stream not finished cleanly: %v", err)
+	}
+}
@@ -14,6 +14,17 @@ type Config struct {
 	Database   DatabaseConfig   `yaml:"database"`
 	Migrations MigrationsConfig `yaml:"migrations"`
 	Logging    LoggingConfig    `yaml:"logging"`
+	Search     SearchConfig     `yaml:"search"`
+	Moderation ModerationConfig `yaml:"moderation"`
+	Spam       SpamConfig       `yaml:"spam"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Comments   CommentsConfig   `yaml:"comments"`
+	Stream     StreamConfig     `yaml:"stream"`
+	Events     EventsConfig     `yaml:"events"`
+	Webhooks   WebhooksConfig   `yaml:"webhooks"`
+	Outbox     OutboxConfig     `yaml:"outbox"`
+	Validation ValidationConfig `yaml:"validation"`
 }
 
 type ServerConfig struct {
@@ -21,6 +32,9 @@ type ServerConfig struct {
 	ShutdownTimeoutSec int    `yaml:"shutdown_timeout_sec"`
 	ReadTimeoutSec     int    `yaml:"read_timeout_sec"`
 	WriteTimeoutSec    int    `yaml:"write_timeout_sec"`
+	// TrustedProxies — адреса и подсети прокси, чьим X-Forwarded-For можно верить;
+	// пусто — IP клиента берётся из соединения
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -37,6 +51,129 @@ type MigrationsConfig struct {
 	Path string `yaml:"path"`
 }
 
+type SearchConfig struct {
+	HighlightStart string `yaml:"highlight_start"`
+	HighlightStop  string `yaml:"highlight_stop"`
+	// Индексация комментариев, записанных до появления поискового индекса
+	BackfillBatchSize int `yaml:"backfill_batch_size"`
+	BackfillPauseMS   int `yaml:"backfill_pause_ms"`
+}
+
+type ModerationConfig struct {
+	// Premoderation включает премодерацию во всех обсуждениях
+	Premoderation       bool     `yaml:"premoderation"`
+	PremoderatedThreads []string `yaml:"premoderated_threads"`
+	ModeratorKey        string   `yaml:"moderator_key"`
+	// AdminKey даёт права администратора (в том числе управление вебхуками) без SSO
+	AdminKey string `yaml:"admin_key"`
+}
+
+type SpamConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Суммарная оценка классификаторов, начиная с которой комментарий уходит
+	// на модерацию или сразу отклоняется; 0 отключает порог
+	PendingThreshold  float64  `yaml:"pending_threshold"`
+	RejectThreshold   float64  `yaml:"reject_threshold"`
+	MaxLinks          int      `yaml:"max_links"`
+	LinkWeight        float64  `yaml:"link_weight"`
+	Blocklist         []string `yaml:"blocklist"`
+	BlocklistPatterns []string `yaml:"blocklist_patterns"`
+	BlocklistWeight   float64  `yaml:"blocklist_weight"`
+	MaxRepeatedChars  int      `yaml:"max_repeated_chars"`
+	RepeatedWeight    float64  `yaml:"repeated_weight"`
+	BayesWeight       float64  `yaml:"bayes_weight"`
+	BayesMinSamples   int      `yaml:"bayes_min_samples"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Store — memory (одна реплика) или postgres (общие лимиты для всех реплик)
+	Store     string      `yaml:"store"`
+	BucketTTL int         `yaml:"bucket_ttl_sec"`
+	IP        LimitConfig `yaml:"ip"`
+	Author    LimitConfig `yaml:"author"`
+	Thread    LimitConfig `yaml:"thread"`
+}
+
+type LimitConfig struct {
+	PerMinute float64 `yaml:"per_minute"`
+	Burst     int     `yaml:"burst"`
+}
+
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// AllowAnonymous разрешает писать без токена, указывая имя в запросе
+	AllowAnonymous      bool     `yaml:"allow_anonymous"`
+	Issuer              string   `yaml:"issuer"`
+	Audience            string   `yaml:"audience"`
+	HS256Secret         string   `yaml:"hs256_secret"`
+	RS256PublicKeyFiles []string `yaml:"rs256_public_key_files"`
+	JWKSFile            string   `yaml:"jwks_file"`
+	NameClaim           string   `yaml:"name_claim"`
+	// RolesClaim — список ролей (user, moderator, admin), ThreadsClaim — обсуждения модератора
+	RolesClaim   string `yaml:"roles_claim"`
+	ThreadsClaim string `yaml:"threads_claim"`
+	LeewaySec    int    `yaml:"leeway_sec"`
+}
+
+type CommentsConfig struct {
+	// ManagementTokenWindowSec — сколько секунд после создания анонимный автор
+	// может править и удалять комментарий по токену; 0 отключает токены
+	ManagementTokenWindowSec int `yaml:"management_token_window_sec"`
+	// Сколько уровней ответов отдаёт GET /comments/:id без параметра depth и сколько не больше
+	PermalinkDepth    int `yaml:"permalink_depth"`
+	MaxPermalinkDepth int `yaml:"max_permalink_depth"`
+}
+
+type StreamConfig struct {
+	// BufferSize — сколько последних событий хранится для возобновления по Last-Event-ID
+	BufferSize   int `yaml:"buffer_size"`
+	HeartbeatSec int `yaml:"heartbeat_sec"`
+}
+
+type EventsConfig struct {
+	// Bus — memory (один экземпляр) или postgres (LISTEN/NOTIFY для нескольких реплик)
+	Bus     string `yaml:"bus"`
+	Channel string `yaml:"channel"`
+}
+
+type WebhooksConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxAttempts — сколько попыток доставки, прежде чем событие уйдёт в dead-letter
+	MaxAttempts int `yaml:"max_attempts"`
+	// Пауза перед повтором: backoff_initial_sec * backoff_factor^(попытка-1)
+	BackoffInitialSec int     `yaml:"backoff_initial_sec"`
+	BackoffFactor     float64 `yaml:"backoff_factor"`
+	BatchSize         int     `yaml:"batch_size"`
+	PollIntervalMS    int     `yaml:"poll_interval_ms"`
+	TimeoutSec        int     `yaml:"timeout_sec"`
+}
+
+type OutboxConfig struct {
+	BatchSize      int `yaml:"batch_size"`
+	PollIntervalMS int `yaml:"poll_interval_ms"`
+	// RetentionHours — сколько хранить опубликованные события; 0 — не удалять
+	RetentionHours int `yaml:"retention_hours"`
+}
+
+type ValidationConfig struct {
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// Длины считаются в символах после нормализации (trim + NFC); 0 — без ограничения
+	MaxContentLength   int `yaml:"max_content_length"`
+	MaxAuthorLength    int `yaml:"max_author_length"`
+	MaxThreadKeyLength int `yaml:"max_thread_key_length"`
+	// Категории Unicode (L, Lu, N, P, S, Zs, ...), из символов которых может состоять текст;
+	// пустой список разрешает всё, кроме управляющих символов
+	ContentCategories []string `yaml:"content_categories"`
+	AuthorCategories  []string `yaml:"author_categories"`
+	// MaxDepth — наибольший уровень вложенности (корневой комментарий — уровень 1); 0 — без ограничения
+	MaxDepth int `yaml:"max_depth"`
+	// Пагинация: limit приводится к [1, max_limit], offset — к [0, max_offset]
+	DefaultLimit int `yaml:"default_limit"`
+	MaxLimit     int `yaml:"max_limit"`
+	MaxOffset    int `yaml:"max_offset"`
+}
+
 type LoggingConfig struct {
 	Level string `yaml:"level"`
 }
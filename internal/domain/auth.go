@@ -0,0 +1,48 @@
+package domain
+
+import "context"
+
+type Role string
+
+const (
+	RoleAnonymous Role = "anonymous"
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRank = map[Role]int{RoleAnonymous: 0, RoleUser: 1, RoleModerator: 2, RoleAdmin: 3}
+
+// HighestRole выбирает самую сильную из известных ролей; без известных — user
+func HighestRole(names []string) Role {
+	best := RoleUser
+	for _, name := range names {
+		if r := Role(name); roleRank[r] > roleRank[best] {
+			best = r
+		}
+	}
+	return best
+}
+
+// Principal — пользователь, подтверждённый токеном SSO
+type Principal struct {
+	Subject string
+	Name    string
+	Role    Role
+	// Threads — обсуждения, которые модерирует пользователь; глобальному модератору
+	// нужен явный AllThreads, без claim модератор не модерирует ничего
+	Threads []string
+	Claims  map[string]any
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает аутентифицированного пользователя запроса, если он есть.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
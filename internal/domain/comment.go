@@ -3,12 +3,62 @@ package domain
 import "time"
 
 type Comment struct {
-	ID        int64      `json:"id"`
-	ParentID  *int64     `json:"parent_id,omitempty"`
-	Content   string     `json:"content"`
-	Author    string     `json:"author"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
-	Deleted   bool       `json:"deleted"`
-	Children  []*Comment `json:"children,omitempty"`
+	ID        int64  `json:"id"`
+	ParentID  *int64 `json:"parent_id,omitempty"`
+	ThreadKey string `json:"thread_key"`
+	Content   string `json:"content"`
+	// ContentHTML — Content, отрендеренный из Markdown при записи; пусто у старых комментариев
+	ContentHTML string `json:"content_html,omitempty"`
+	Author      string `json:"author"`
+	// AuthorSubject — субъект токена автора; пусто у анонимных комментариев.
+	// Права владельца определяются только по нему, не по имени.
+	AuthorSubject string     `json:"author_subject,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+	EditedBy      *string    `json:"edited_by,omitempty"`
+	EditedAt      *time.Time `json:"edited_at,omitempty"`
+	Deleted       bool       `json:"deleted"`
+	Upvotes       int        `json:"upvotes"`
+	Downvotes     int        `json:"downvotes"`
+	Score         int        `json:"score"`
+	// Locked запрещает новые ответы в ветке этого комментария всем, кроме модераторов
+	Locked bool `json:"locked"`
+
+	Status           ModerationStatus `json:"status"`
+	ModerationReason *string          `json:"moderation_reason,omitempty"`
+	ModeratedBy      *string          `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time       `json:"moderated_at,omitempty"`
+
+	// ManagementToken выдаётся анонимному автору один раз, в ответе на создание;
+	// в базе хранится только ManagementTokenHash.
+	ManagementToken     string `json:"-"`
+	ManagementTokenHash []byte `json:"-"`
+
+	Children []*Comment `json:"children,omitempty"`
+	// SortKey — ключ сортировки по голосам, вычисленный БД; нужен для курсоров.
+	SortKey float64 `json:"-"`
+}
+
+// CommentContext — комментарий по постоянной ссылке: цепочка предков от корня обсуждения
+// и ответы (в Comment.Children) на Depth уровней вниз.
+type CommentContext struct {
+	Comment   *Comment
+	Ancestors []*Comment
+	Depth     int
+}
+
+// ThreadSummary — сводка по одному обсуждению; учитываются только одобренные неудалённые комментарии.
+type ThreadSummary struct {
+	Key           string
+	CommentCount  int64
+	LastCommentAt time.Time
+}
+
+// Tombstone скрывает содержимое и автора удалённого комментария, сохраняя его место в дереве.
+func (c *Comment) Tombstone() {
+	c.Content = ""
+	c.ContentHTML = ""
+	c.Author = ""
+	c.AuthorSubject = ""
+	c.EditedBy = nil
 }
@@ -1,5 +1,110 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
-var ErrCommentNotFound = errors.New("comment not found")
+// Категории ошибок. По категории ошибка сопоставляется с HTTP-статусом:
+// errors.Is(err, ErrNotFound) истинно для любой ошибки «не найдено», в том числе ErrCommentNotFound.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("authentication required")
+	ErrRateLimited  = errors.New("rate limit exceeded")
+	ErrTooLarge     = errors.New("payload too large")
+	ErrUnavailable  = errors.New("service unavailable")
+)
+
+var (
+	ErrCommentNotFound   = &Error{Kind: ErrNotFound, Message: "comment not found"}
+	ErrParentNotFound    = &Error{Kind: ErrNotFound, Message: "parent comment not found"}
+	ErrCommentDeleted    = &Error{Kind: ErrConflict, Message: "comment is deleted"}
+	ErrParentDeleted     = &Error{Kind: ErrConflict, Message: "cannot reply to a deleted comment"}
+	ErrParentLocked      = &Error{Kind: ErrForbidden, Message: "replies to this comment are locked"}
+	ErrRevisionNotFound  = &Error{Kind: ErrNotFound, Message: "revision not found"}
+	ErrThreadKeyMismatch = Invalid("thread_key", "does not match parent comment")
+	ErrInvalidVote       = Invalid("value", "must be -1, 0 or 1")
+	ErrStreamUnavailable = &Error{Kind: ErrUnavailable, Message: "live stream is not available"}
+	ErrWebhookNotFound   = &Error{Kind: ErrNotFound, Message: "webhook not found"}
+	ErrDeliveryNotFound  = &Error{Kind: ErrNotFound, Message: "webhook delivery not found"}
+	ErrAlreadyExists     = &Error{Kind: ErrConflict, Message: "already exists"}
+	ErrConcurrentUpdate  = &Error{Kind: ErrConflict, Message: "concurrent update, retry the request"}
+)
+
+// BodyTooLarge сообщает, что тело запроса длиннее limit байт
+func BodyTooLarge(limit int64) error {
+	return &Error{Kind: ErrTooLarge, Message: fmt.Sprintf("request body must not exceed %d bytes", limit)}
+}
+
+// Error — ошибка предметной области; Kind — одна из категорий выше
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// FieldError — ошибка в одном поле запроса
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError — запрос не прошёл проверку; errors.Is(err, ErrValidation) для него истинно
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Invalid возвращает ошибку проверки одного поля
+func Invalid(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add добавляет ошибку поля; так проверка собирает все ошибки запроса, а не только первую
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err возвращает e, если есть ошибки, иначе nil
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// ForbiddenError — отказ политики доступа; errors.Is(err, ErrForbidden) для него истинно
+type ForbiddenError struct {
+	Action string
+	Reason string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("forbidden: %s: %s", e.Action, e.Reason)
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type CommentEventType string
+
+const (
+	EventCommentCreated  CommentEventType = "comment.created"
+	EventCommentEdited   CommentEventType = "comment.edited"
+	EventCommentDeleted  CommentEventType = "comment.deleted"
+	EventCommentRestored CommentEventType = "comment.restored"
+	// EventCommentModerated — модератор одобрил или отклонил комментарий
+	EventCommentModerated CommentEventType = "comment.moderated"
+	// EventCommentLocked — ветку закрыли для ответов или открыли снова (см. Comment.Locked)
+	EventCommentLocked CommentEventType = "comment.locked"
+	// EventStreamReset — подписчик отстал дальше буфера; часть событий потеряна,
+	// и клиенту нужно перечитать обсуждение целиком
+	EventStreamReset CommentEventType = "stream.reset"
+)
+
+// CommentEvent — изменение комментария для живой ленты
+type CommentEvent struct {
+	// ID — уникальный идентификатор события; повторная доставка приходит с тем же ID
+	ID string
+	// Seq — номер события в outbox, общий для всех экземпляров; по нему клиент
+	// возобновляет ленту (Last-Event-ID). 0 — событие не из outbox
+	Seq     int64
+	Type    CommentEventType
+	Comment *Comment
+	// Subtree — удалено или восстановлено всё поддерево комментария
+	Subtree bool
+	At      time.Time
+}
+
+// StreamQuery — какие события нужны подписчику
+type StreamQuery struct {
+	ThreadKey string
+	// ParentID — только ответы на этот комментарий
+	ParentID    *int64
+	LastEventID int64
+}
+
+// Matches сообщает, относится ли событие к выбранному обсуждению и ветке
+func (q StreamQuery) Matches(e *CommentEvent) bool {
+	if e.Comment == nil {
+		return true
+	}
+	if q.ThreadKey != "" && q.ThreadKey != e.Comment.ThreadKey {
+		return false
+	}
+	if q.ParentID != nil && (e.Comment.ParentID == nil || *e.Comment.ParentID != *q.ParentID) {
+		return false
+	}
+	return true
+}
+
+// EventHandler обрабатывает событие, пришедшее по шине
+type EventHandler func(ctx context.Context, e *CommentEvent)
+
+// EventPublisher отправляет события о комментариях
+type EventPublisher interface {
+	Publish(ctx context.Context, e *CommentEvent) error
+}
+
+// EventBus доставляет события всем экземплярам сервиса. Обработчики вызываются
+// для каждого события, в том числе опубликованного этим же экземпляром.
+type EventBus interface {
+	EventPublisher
+	Subscribe(h EventHandler)
+}
+
+// EventStream раздаёт события подписчикам живой ленты этого экземпляра
+type EventStream interface {
+	// Subscribe возвращает события, пришедшие после события с номером after:
+	// сначала сохранённые, затем новые.
+	// Канал закрывается, когда ctx отменён или подписчик не успевает читать.
+	Subscribe(ctx context.Context, after int64) <-chan *CommentEvent
+}
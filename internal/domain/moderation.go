@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+type ModerationStatus string
+
+const (
+	StatusPending  ModerationStatus = "pending"
+	StatusApproved ModerationStatus = "approved"
+	StatusRejected ModerationStatus = "rejected"
+)
+
+// AllThreads в списке обсуждений модератора означает все обсуждения
+const AllThreads = "*"
+
+// SpamFilterModerator — имя, под которым спам-фильтр отклоняет комментарии
+const SpamFilterModerator = "spam-filter"
+
+// Viewer — тот, кто читает или пишет комментарии; от него зависят видимость
+// непромодерированных комментариев и права на правку и удаление.
+type Viewer struct {
+	Name string
+	// Subject — субъект токена; по нему, а не по имени, читатель узнаётся как автор
+	Subject string
+	Role    Role
+	// Threads — обсуждения, которые модерирует модератор; AllThreads — все, пусто — ни одного
+	Threads []string
+	// ManagementToken — токен управления комментарием, предъявленный анонимным автором
+	ManagementToken string
+}
+
+// IsModerator сообщает, модерирует ли читатель хотя бы одно обсуждение
+func (v Viewer) IsModerator() bool {
+	return v.Role == RoleAdmin || (v.Role == RoleModerator && len(v.Threads) > 0)
+}
+
+// Moderates сообщает, может ли читатель модерировать обсуждение threadKey
+func (v Viewer) Moderates(threadKey string) bool {
+	switch v.Role {
+	case RoleAdmin:
+		return true
+	case RoleModerator:
+		return slices.Contains(v.Threads, AllThreads) || slices.Contains(v.Threads, threadKey)
+	}
+	return false
+}
+
+// ModeratesAll сообщает, модерирует ли читатель все обсуждения сразу
+func (v Viewer) ModeratesAll() bool {
+	return v.Role == RoleAdmin || (v.Role == RoleModerator && slices.Contains(v.Threads, AllThreads))
+}
+
+// CanSee сообщает, виден ли комментарий читателю: одобренные видны всем,
+// ожидающие модерации — автору и модераторам, отклонённые — только модераторам.
+func (v Viewer) CanSee(c *Comment) bool {
+	switch {
+	case v.Moderates(c.ThreadKey):
+		return true
+	case c.Status == StatusPending:
+		return v.IsAuthor(c)
+	case c.Status == StatusRejected:
+		return false
+	}
+	return true
+}
+
+// IsAuthor сообщает, что комментарий написал сам читатель. Нужен подтверждённый
+// токеном субъект: имя автора анонимного комментария может быть любым.
+func (v Viewer) IsAuthor(c *Comment) bool {
+	return v.Subject != "" && v.Subject == c.AuthorSubject
+}
+
+type viewerKey struct{}
+
+func WithViewer(ctx context.Context, v Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, v)
+}
+
+// ViewerFromContext возвращает читателя запроса; без него — анонимный читатель.
+func ViewerFromContext(ctx context.Context) Viewer {
+	v, _ := ctx.Value(viewerKey{}).(Viewer)
+	return v
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "testing"
+
+func TestViewerCanSee(t *testing.T) {
+	comment := func(status ModerationStatus, subject string) *Comment {
+		return &Comment{ThreadKey: "t", Author: "alice", AuthorSubject: subject, Status: status}
+	}
+	alice := Viewer{Name: "alice", Subject: "sub-alice", Role: RoleUser}
+	namesake := Viewer{Name: "alice", Subject: "sub-other", Role: RoleUser}
+	guest := Viewer{Name: "alice", Role: RoleAnonymous}
+	mod := Viewer{Role: RoleModerator, Threads: []string{"t"}}
+
+	tests := []struct {
+		name    string
+		viewer  Viewer
+		comment *Comment
+		want    bool
+	}{
+		{"approved to anyone", Viewer{}, comment(StatusApproved, ""), true},
+		{"pending to its author", alice, comment(StatusPending, "sub-alice"), true},
+		{"pending hidden from a namesake", namesake, comment(StatusPending, "sub-alice"), false},
+		{"anonymous pending hidden from a name match", alice, comment(StatusPending, ""), false},
+		{"pending hidden from anonymous", guest, comment(StatusPending, ""), false},
+		{"pending to thread moderator", mod, comment(StatusPending, ""), true},
+		{"rejected hidden from author", alice, comment(StatusRejected, "sub-alice"), false},
+		{"rejected to thread moderator", mod, comment(StatusRejected, ""), true},
+	}
+	for _, tt := range tests {
+		if got := tt.viewer.CanSee(tt.comment); got != tt.want {
+			t.Errorf("%s: CanSee() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type NotificationKind string
+
+const (
+	NotificationMention NotificationKind = "mention"
+	NotificationReply   NotificationKind = "reply"
+)
+
+// Notification — событие для автора: его упомянули или ответили на его комментарий
+type Notification struct {
+	ID        int64
+	Recipient string
+	Kind      NotificationKind
+	CommentID int64
+	ThreadKey string
+	// Actor — автор комментария, вызвавшего уведомление
+	Actor string
+	// Excerpt — начало текста комментария; пусто, если он удалён или сейчас не одобрен
+	Excerpt   string
+	CreatedAt time.Time
+	ReadAt    *time.Time
+}
+
+type NotificationPage struct {
+	Items       []*Notification
+	UnreadCount int64
+	Next        *Cursor
+	Prev        *Cursor
+}
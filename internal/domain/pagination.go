@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Cursor указывает на позицию в упорядоченной выборке комментариев.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+	// Score — ключ ранжирования для сортировок не по времени (например, релевантность поиска).
+	Score *float64
+}
+
+// PageRequest задаёт страницу либо смещением, либо курсором (After/Before).
+// При наличии курсора Offset игнорируется.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	After  *Cursor
+	Before *Cursor
+}
+
+// ThreadQuery описывает выборку веток: детей ParentID (или корней, если он nil).
+type ThreadQuery struct {
+	ParentID *int64
+	// ThreadKey ограничивает выборку одним обсуждением; без ParentID он обязателен.
+	ThreadKey string
+	Sort      string
+	Page      PageRequest
+	// Viewer определяет, какие непромодерированные комментарии видны.
+	Viewer Viewer
+	// HideDeleted убирает удалённые комментарии вместе с ответами вместо показа заглушек.
+	HideDeleted bool
+}
+
+type CommentPage struct {
+	Items []*Comment
+	Next  *Cursor
+	Prev  *Cursor
+}
+
+type SearchPage struct {
+	Hits []*SearchHit
+	Next *Cursor
+	Prev *Cursor
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	SortAsc           = "asc"
+	SortDesc          = "desc"
+	SortTop           = "top"
+	SortBest          = "best"
+	SortControversial = "controversial"
+	SortHot           = "hot"
+)
+
+// ParseSort проверяет значение сортировки; пустая строка означает asc.
+func ParseSort(s string) (string, error) {
+	switch s {
+	case "":
+		return SortAsc, nil
+	case SortAsc, SortDesc, SortTop, SortBest, SortControversial, SortHot:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown sort %q", s)
+	}
+}
+
+// IsScoreSort сообщает, упорядочивается ли выборка по голосам, а не по времени.
+func IsScoreSort(sort string) bool {
+	switch sort {
+	case SortTop, SortBest, SortControversial, SortHot:
+		return true
+	}
+	return false
+}
+
+// hotEpoch и hotPeriod — константы формулы hot: каждые 12.5 часов весят как десятикратный рост счёта.
+const (
+	hotEpoch  = 1134028003
+	hotPeriod = 45000
+)
+
+// SortKey вычисляет ключ сортировки по голосам; чем больше, тем выше комментарий.
+// Формулы совпадают с SQL-выражениями репозитория.
+func SortKey(sort string, c *Comment) float64 {
+	switch sort {
+	case SortTop:
+		return float64(c.Score)
+	case SortBest:
+		return WilsonLowerBound(c.Upvotes, c.Downvotes)
+	case SortControversial:
+		return Controversy(c.Upvotes, c.Downvotes)
+	case SortHot:
+		return Hotness(c.Score, c.CreatedAt)
+	}
+	return 0
+}
+
+// WilsonLowerBound — нижняя граница доверительного интервала Уилсона (95%) для доли голосов «за».
+func WilsonLowerBound(up, down int) float64 {
+	n := float64(up + down)
+	if n == 0 {
+		return 0
+	}
+	const z = 1.96
+	p := float64(up) / n
+	return (p + z*z/(2*n) - z*math.Sqrt(float64(up)*float64(down)/n+z*z/4)/n) / (1 + z*z/n)
+}
+
+// Controversy растёт с числом голосов и тем сильнее, чем ближе «за» и «против» друг к другу.
+func Controversy(up, down int) float64 {
+	if up <= 0 || down <= 0 {
+		return 0
+	}
+	magnitude := float64(up + down)
+	balance := float64(min(up, down)) / float64(max(up, down))
+	return math.Pow(magnitude, balance)
+}
+
+// Hotness сочетает счёт и свежесть: логарифм счёта плюс линейный вклад времени создания.
+func Hotness(score int, createdAt time.Time) float64 {
+	order := math.Log10(math.Max(math.Abs(float64(score)), 1))
+	sign := 0.0
+	if score > 0 {
+		sign = 1
+	} else if score < 0 {
+		sign = -1
+	}
+	seconds := float64(createdAt.UnixMicro())/1e6 - hotEpoch
+	return sign*order + seconds/hotPeriod
+}
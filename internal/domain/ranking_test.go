@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWilsonLowerBound(t *testing.T) {
+	tests := []struct {
+		up, down int
+		want     float64
+	}{
+		{0, 0, 0},
+		{1, 0, 0.2065},
+		{0, 1, 0},
+		{10, 0, 0.7225},
+		{10, 10, 0.2993},
+		{100, 0, 0.9630},
+	}
+	for _, tt := range tests {
+		if got := WilsonLowerBound(tt.up, tt.down); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("WilsonLowerBound(%d, %d) = %.4f, want %.4f", tt.up, tt.down, got, tt.want)
+		}
+	}
+
+	// Больше голосов при той же доле — выше нижняя граница
+	order := [][2]int{{1, 0}, {5, 1}, {10, 0}, {100, 1}}
+	for i := 1; i < len(order); i++ {
+		a, b := order[i-1], order[i]
+		if WilsonLowerBound(a[0], a[1]) >= WilsonLowerBound(b[0], b[1]) {
+			t.Errorf("WilsonLowerBound%v >= WilsonLowerBound%v", a, b)
+		}
+	}
+}
+
+func TestControversy(t *testing.T) {
+	tests := []struct {
+		up, down int
+		want     float64
+	}{
+		{0, 0, 0},
+		{5, 0, 0},
+		{0, 5, 0},
+		{5, 5, 10},
+		{1, 4, math.Pow(5, 0.25)},
+		{4, 1, math.Pow(5, 0.25)},
+	}
+	for _, tt := range tests {
+		if got := Controversy(tt.up, tt.down); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Controversy(%d, %d) = %v, want %v", tt.up, tt.down, got, tt.want)
+		}
+	}
+}
+
+func TestHotness(t *testing.T) {
+	epoch := time.Unix(hotEpoch, 0)
+
+	tests := []struct {
+		name  string
+		score int
+		at    time.Time
+		want  float64
+	}{
+		{"zero at epoch", 0, epoch, 0},
+		{"score one counts as zero", 1, epoch, 0},
+		{"ten votes", 10, epoch, 1},
+		{"ten downvotes", -10, epoch, -1},
+		{"one period later", 0, epoch.Add(hotPeriod * time.Second), 1},
+		{"sub-second precision", 0, epoch.Add(hotPeriod * time.Millisecond), 0.001},
+	}
+	for _, tt := range tests {
+		if got := Hotness(tt.score, tt.at); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: Hotness(%d, %v) = %v, want %v", tt.name, tt.score, tt.at, got, tt.want)
+		}
+	}
+
+	// 12.5 часов свежести весят как десятикратный рост счёта
+	older := Hotness(100, epoch)
+	newer := Hotness(10, epoch.Add(hotPeriod*time.Second))
+	if math.Abs(older-newer) > 1e-9 {
+		t.Errorf("Hotness(100, t) = %v, Hotness(10, t+period) = %v, want equal", older, newer)
+	}
+}
+
+func TestSortKey(t *testing.T) {
+	c := &Comment{Upvotes: 7, Downvotes: 3, Score: 4, CreatedAt: time.Unix(hotEpoch+hotPeriod, 0)}
+
+	tests := []struct {
+		sort string
+		want float64
+	}{
+		{SortTop, 4},
+		{SortBest, WilsonLowerBound(7, 3)},
+		{SortControversial, Controversy(7, 3)},
+		{SortHot, Hotness(4, c.CreatedAt)},
+		{SortAsc, 0},
+	}
+	for _, tt := range tests {
+		if got := SortKey(tt.sort, c); got != tt.want {
+			t.Errorf("SortKey(%q) = %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,97 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
+// CommentRepository хранит комментарии. Изменяющие методы (Save, Update, Delete, Restore,
+// SetStatus, SetLocked) в той же транзакции записывают событие в outbox.
 type CommentRepository interface {
 	Save(ctx context.Context, comment *Comment) error
 	FindByID(ctx context.Context, id int64) (*Comment, error)
-	FindChildren(ctx context.Context, parentID *int64, limit, offset int, sort string) ([]*Comment, error)
+	FindChildren(ctx context.Context, query ThreadQuery) ([]*Comment, error)
+	// FindSubtree возвращает плоский список всех потомков rootIDs (без самих корней),
+	// включая удалённые — решение об их показе принимает вызывающий.
+	// maxDepth <= 0 означает неограниченную глубину.
+	FindSubtree(ctx context.Context, rootIDs []int64, maxDepth int) ([]*Comment, error)
+	// FindAncestors возвращает цепочку предков комментария от корня обсуждения
+	// до непосредственного родителя; у корневого комментария она пуста.
+	FindAncestors(ctx context.Context, id int64) ([]*Comment, error)
+	// Update меняет текст и статус модерации комментария edit.ID, сохраняя предыдущую
+	// версию в истории правок. Из edit берутся Content, ContentHTML и поля модерации.
+	Update(ctx context.Context, edit *Comment, editor string) (*Comment, error)
+	ListRevisions(ctx context.Context, commentID int64) ([]*Revision, error)
+	// Vote сохраняет голос voter (+1/-1, 0 — отозвать) и пересчитывает счётчики комментария.
+	Vote(ctx context.Context, commentID int64, voter string, value int) (*Comment, error)
+	// Delete и Restore мягко удаляют/восстанавливают комментарий (при cascade — всё поддерево)
+	// и возвращают число изменённых строк.
+	Delete(ctx context.Context, id int64, cascade bool) (int64, error)
+	ListThreads(ctx context.Context, limit, offset int) ([]*ThreadSummary, error)
+	// FindByStatus возвращает неудалённые комментарии в статусе модерации, старые первыми;
+	// непустой threadKeys ограничивает выборку этими обсуждениями.
+	FindByStatus(ctx context.Context, status ModerationStatus, threadKeys []string, page PageRequest) ([]*Comment, error)
+	// SetStatus применяет решение модератора к комментариям и возвращает изменённые.
+	SetStatus(ctx context.Context, ids []int64, status ModerationStatus, moderator, reason string) ([]*Comment, error)
+	// FindModerated возвращает последние комментарии с решением модератора
+	// (одобренные или отклонённые), кроме решений модератора exclude.
+	FindModerated(ctx context.Context, exclude string, limit int) ([]*Comment, error)
+	Restore(ctx context.Context, id int64, cascade bool) (int64, error)
+	// SetLocked закрывает (или снова открывает) ветку комментария для новых ответов.
+	SetLocked(ctx context.Context, id int64, locked bool) (*Comment, error)
+	// FindManagementTokenHash возвращает хеш токена управления; nil, если токена нет.
+	FindManagementTokenHash(ctx context.Context, id int64) ([]byte, error)
+	Search(ctx context.Context, query SearchQuery) ([]*SearchHit, error)
+}
+
+type NotificationRepository interface {
+	// SaveMentions запоминает, кого упомянули в комментарии
+	SaveMentions(ctx context.Context, commentID int64, usernames []string) error
+	// Create сохраняет уведомления; уже существующие (тот же получатель, тип и комментарий) пропускаются
+	Create(ctx context.Context, notifications []*Notification) error
+	// List возвращает уведомления получателя, новые первыми
+	List(ctx context.Context, recipient string, unreadOnly bool, page PageRequest) ([]*Notification, error)
+	CountUnread(ctx context.Context, recipient string) (int64, error)
+	// MarkRead отмечает прочитанными уведомления с ids или все, если ids пуст
+	MarkRead(ctx context.Context, recipient string, ids []int64) (int64, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, w *Webhook) error
+	List(ctx context.Context) ([]*Webhook, error)
+	FindByID(ctx context.Context, id int64) (*Webhook, error)
+	Update(ctx context.Context, w *Webhook) error
 	Delete(ctx context.Context, id int64) error
-	Search(ctx context.Context, query string, limit, offset int) ([]*Comment, error)
+
+	// Enqueue ставит событие в очередь всем активным вебхукам, подписанным на eventType;
+	// повторное событие с тем же eventID пропускается. Возвращает число новых доставок.
+	Enqueue(ctx context.Context, eventID, eventType string, payload []byte) (int64, error)
+	// ClaimDue забирает до limit доставок, время которых пришло, и откладывает их на lease,
+	// чтобы другие экземпляры не взяли их одновременно.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// RecordAttempt пишет попытку в журнал и сохраняет новое состояние доставки.
+	RecordAttempt(ctx context.Context, d *WebhookDelivery, a *DeliveryAttempt) error
+	// ListDeliveries возвращает доставки вебхука, новые первыми; пустой status — все.
+	ListDeliveries(ctx context.Context, webhookID int64, status DeliveryStatus, page PageRequest) ([]*WebhookDelivery, error)
+	FindDelivery(ctx context.Context, webhookID, deliveryID int64) (*WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryID int64) ([]*DeliveryAttempt, error)
+	// Replay возвращает доставку в очередь с новым лимитом попыток; номера попыток продолжают расти.
+	Replay(ctx context.Context, webhookID, deliveryID int64) (*WebhookDelivery, error)
+}
+
+// SearchIndexRepository дозаполняет поисковый индекс комментариев, записанных до его появления
+type SearchIndexRepository interface {
+	// IndexMissing строит индекс для не более limit комментариев без него с id > afterID.
+	// Возвращает наибольший обработанный id и число обработанных; 0 — индексировать больше нечего.
+	IndexMissing(ctx context.Context, afterID int64, limit int) (lastID int64, indexed int, err error)
+}
+
+// OutboxRepository читает события, записанные в outbox вместе с изменениями комментариев
+type OutboxRepository interface {
+	// Drain отмечает опубликованными до limit событий в порядке записи и возвращает их.
+	// deliver вызывается для каждого события в той же транзакции (ctx несёт её): если он
+	// вернул ошибку, транзакция откатывается и ни одно событие пачки не отмечается.
+	Drain(ctx context.Context, limit int, deliver func(context.Context, *CommentEvent) error) ([]*CommentEvent, error)
+	// Cleanup удаляет события, опубликованные раньше before
+	Cleanup(ctx context.Context, before time.Time) (int64, error)
 }
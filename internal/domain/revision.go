@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Revision — одна версия текста комментария.
+// Number начинается с 1 (исходный текст); Current помечает текущую версию.
+type Revision struct {
+	CommentID int64     `json:"comment_id"`
+	Number    int       `json:"number"`
+	Content   string    `json:"content"`
+	Editor    string    `json:"editor"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffChunk — фрагмент разницы между двумя версиями текста.
+type DiffChunk struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type RevisionDiff struct {
+	From   int         `json:"from"`
+	To     int         `json:"to"`
+	Chunks []DiffChunk `json:"chunks"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "fmt"
+
+// SearchMode определяет, как пользовательский запрос превращается в tsquery.
+type SearchMode string
+
+const (
+	SearchModePlain     SearchMode = "plain"
+	SearchModePhrase    SearchMode = "phrase"
+	SearchModeWebsearch SearchMode = "websearch"
+)
+
+// ParseSearchMode разбирает режим поиска; пустая строка означает websearch.
+func ParseSearchMode(s string) (SearchMode, error) {
+	switch SearchMode(s) {
+	case "":
+		return SearchModeWebsearch, nil
+	case SearchModePlain, SearchModePhrase, SearchModeWebsearch:
+		return SearchMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown search mode %q", s)
+	}
+}
+
+// SearchQuery описывает один запрос полнотекстового поиска.
+type SearchQuery struct {
+	Text      string
+	Mode      SearchMode
+	ThreadKey string
+	Viewer    Viewer
+	Page      PageRequest
+	// HighlightStart и HighlightStop обрамляют совпадения в сниппете.
+	HighlightStart string
+	HighlightStop  string
+}
+
+const (
+	MatchedFieldContent = "content"
+	MatchedFieldAuthor  = "author"
+)
+
+// SearchHit — найденный комментарий вместе с объяснением, почему он найден.
+type SearchHit struct {
+	Comment       *Comment
+	Snippet       string
+	Rank          float64
+	MatchedFields []string
+}
@@ -3,8 +3,40 @@ package domain
 import "context"
 
 type CommentService interface {
-	CreateComment(ctx context.Context, parentID *int64, author, content string) (*Comment, error)
-	GetThread(ctx context.Context, parentID *int64, limit, offset int, sort string) ([]*Comment, error)
-	DeleteThread(ctx context.Context, id int64) error
-	SearchComment(ctx context.Context, query string, limit, offset int) ([]*Comment, error)
+	CreateComment(ctx context.Context, parentID *int64, threadKey, author, content string) (*Comment, error)
+	GetThread(ctx context.Context, query ThreadQuery) (*CommentPage, error)
+	// GetComment возвращает комментарий с предками и depth уровнями ответов; depth < 0 — глубина по умолчанию
+	GetComment(ctx context.Context, id int64, depth int) (*CommentContext, error)
+	EditComment(ctx context.Context, id int64, editor, content string) (*Comment, error)
+	ListRevisions(ctx context.Context, id int64) ([]*Revision, error)
+	VoteComment(ctx context.Context, id int64, value int) (*Comment, error)
+	DiffRevisions(ctx context.Context, id int64, from, to int) (*RevisionDiff, error)
+	DeleteThread(ctx context.Context, id int64, cascade bool) error
+	RestoreThread(ctx context.Context, id int64, cascade bool) error
+	LockComment(ctx context.Context, id int64, locked bool) (*Comment, error)
+	ListThreads(ctx context.Context, limit, offset int) ([]*ThreadSummary, error)
+	SearchComment(ctx context.Context, query SearchQuery) (*SearchPage, error)
+	StreamComments(ctx context.Context, query StreamQuery) (<-chan *CommentEvent, error)
+}
+
+type ModerationService interface {
+	ListQueue(ctx context.Context, threadKey string, page PageRequest) (*CommentPage, error)
+	Moderate(ctx context.Context, ids []int64, decision ModerationStatus, reason string) ([]*Comment, error)
+	TrainSpamFilter(ctx context.Context) (int, error)
+}
+
+type NotificationService interface {
+	ListNotifications(ctx context.Context, unreadOnly bool, page PageRequest) (*NotificationPage, error)
+	MarkNotificationsRead(ctx context.Context, ids []int64) (int64, error)
+}
+
+type WebhookService interface {
+	ListWebhooks(ctx context.Context) ([]*Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*Webhook, error)
+	CreateWebhook(ctx context.Context, w *Webhook) (*Webhook, error)
+	UpdateWebhook(ctx context.Context, w *Webhook) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, webhookID int64, status DeliveryStatus, page PageRequest) (*DeliveryPage, error)
+	GetDelivery(ctx context.Context, webhookID, deliveryID int64) (*WebhookDelivery, []*DeliveryAttempt, error)
+	ReplayDelivery(ctx context.Context, webhookID, deliveryID int64) (*WebhookDelivery, error)
 }
@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// Transactor выполняет несколько операций с хранилищем как одно целое. Транзакция
+// передаётся через ctx: методы репозиториев, вызванные с ctx из fn, работают внутри неё.
+// Вложенный вызов присоединяется к уже открытой транзакции.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// Имена событий вебхуков: внешние системы видят их, а не внутренние типы событий
+const (
+	WebhookCommentCreated   = "comment.created"
+	WebhookCommentUpdated   = "comment.updated"
+	WebhookCommentDeleted   = "comment.deleted"
+	WebhookCommentModerated = "comment.moderated"
+)
+
+// WebhookEvents — все события, на которые можно подписаться
+var WebhookEvents = []string{WebhookCommentCreated, WebhookCommentUpdated, WebhookCommentDeleted, WebhookCommentModerated}
+
+// Webhook — подписка внешней системы на события о комментариях
+type Webhook struct {
+	ID  int64
+	URL string
+	// Secret — ключ HMAC-подписи тела запроса
+	Secret string
+	// Events — на какие события подписан; пусто — на все
+	Events    []string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead — попытки исчерпаны; доставку можно только переиграть вручную
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery — доставка одного события одному вебхуку
+type WebhookDelivery struct {
+	ID        int64
+	WebhookID int64
+	EventID   string
+	EventType string
+	Payload   []byte
+	Status    DeliveryStatus
+	// Attempts — сколько всего было попыток, включая сделанные до переигровок;
+	// лимит попыток отсчитывается от AttemptsBeforeReplay
+	Attempts             int
+	AttemptsBeforeReplay int
+	NextAttemptAt        time.Time
+	LastStatusCode       *int
+	LastError            *string
+	CreatedAt            time.Time
+	DeliveredAt          *time.Time
+
+	// URL и Secret вебхука заполняются, когда доставка взята в работу
+	URL    string
+	Secret string
+}
+
+// DeliveryAttempt — запись журнала об одной попытке доставки
+type DeliveryAttempt struct {
+	DeliveryID  int64
+	Attempt     int
+	StatusCode  *int
+	Error       *string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+type DeliveryPage struct {
+	Items []*WebhookDelivery
+	Next  *Cursor
+	Prev  *Cursor
+}
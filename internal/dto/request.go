@@ -1,7 +1,36 @@
 package dto
 
 type CreateCommentRequest struct {
-	ParentID *int64 `json:"parent_id,omitempty"`
-	Author   string `json:"author"`
-	Content  string `json:"content"`
+	ParentID  *int64 `json:"parent_id,omitempty"`
+	ThreadKey string `json:"thread_key,omitempty"`
+	Author    string `json:"author"`
+	Content   string `json:"content"`
+}
+
+type ModerationDecisionRequest struct {
+	IDs    []int64 `json:"ids"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+type MarkNotificationsReadRequest struct {
+	// IDs — какие уведомления отметить; пусто — все
+	IDs []int64 `json:"ids"`
+}
+
+type VoteRequest struct {
+	Value int `json:"value"`
+}
+
+type UpdateCommentRequest struct {
+	Editor  string `json:"editor"`
+	Content string `json:"content"`
+}
+
+type WebhookRequest struct {
+	URL string `json:"url"`
+	// Events — пусто означает все события
+	Events []string `json:"events"`
+	// Secret — ключ подписи; при создании без него генерируется, при изменении пустой оставляет прежний
+	Secret string `json:"secret"`
+	Active *bool  `json:"active"`
 }
@@ -1,14 +1,181 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type CommentResponse struct {
-	ID        int64              `json:"id"`
-	ParentID  *int64             `json:"parent_id,omitempty"`
-	Content   string             `json:"content"`
-	Author    string             `json:"author"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt *time.Time         `json:"updated_at,omitempty"`
-	Deleted   bool               `json:"deleted"`
-	Children  []*CommentResponse `json:"children,omitempty"`
+	ID               int64              `json:"id"`
+	ParentID         *int64             `json:"parent_id,omitempty"`
+	ThreadKey        string             `json:"thread_key"`
+	Content          string             `json:"content"`
+	ContentHTML      string             `json:"content_html,omitempty"`
+	Author           string             `json:"author"`
+	AuthorVerified   bool               `json:"author_verified"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        *time.Time         `json:"updated_at,omitempty"`
+	EditedBy         *string            `json:"edited_by,omitempty"`
+	EditedAt         *time.Time         `json:"edited_at,omitempty"`
+	Deleted          bool               `json:"deleted"`
+	Upvotes          int                `json:"upvotes"`
+	Downvotes        int                `json:"downvotes"`
+	Score            int                `json:"score"`
+	Locked           bool               `json:"locked"`
+	Status           string             `json:"status"`
+	ModerationReason *string            `json:"moderation_reason,omitempty"`
+	ModeratedBy      *string            `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time         `json:"moderated_at,omitempty"`
+	ManagementToken  string             `json:"management_token,omitempty"`
+	Children         []*CommentResponse `json:"children,omitempty"`
+}
+
+// CommentContextResponse — комментарий по постоянной ссылке; ancestors идут от корня к родителю
+type CommentContextResponse struct {
+	Comment   *CommentResponse   `json:"comment"`
+	Ancestors []*CommentResponse `json:"ancestors"`
+	Depth     int                `json:"depth"`
+}
+
+// CommentEventResponse — данные одного события живой ленты
+type CommentEventResponse struct {
+	Type    string           `json:"type"`
+	Comment *CommentResponse `json:"comment,omitempty"`
+	Subtree bool             `json:"subtree,omitempty"`
+	At      time.Time        `json:"at"`
+}
+
+type SearchHitResponse struct {
+	Comment       *CommentResponse `json:"comment"`
+	Snippet       string           `json:"snippet"`
+	Rank          float64          `json:"rank"`
+	MatchedFields []string         `json:"matched_fields"`
+}
+
+type CommentPageResponse struct {
+	Items      []*CommentResponse `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	PrevCursor string             `json:"prev_cursor,omitempty"`
+}
+
+type SearchPageResponse struct {
+	Items      []*SearchHitResponse `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+	PrevCursor string               `json:"prev_cursor,omitempty"`
+}
+
+type RevisionResponse struct {
+	Number    int       `json:"number"`
+	Content   string    `json:"content"`
+	Editor    string    `json:"editor"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+type DiffChunkResponse struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type RevisionDiffResponse struct {
+	From   int                  `json:"from"`
+	To     int                  `json:"to"`
+	Chunks []*DiffChunkResponse `json:"chunks"`
+}
+
+type RevisionsResponse struct {
+	CommentID int64                 `json:"comment_id"`
+	Revisions []*RevisionResponse   `json:"revisions"`
+	Diff      *RevisionDiffResponse `json:"diff,omitempty"`
+}
+
+type ThreadResponse struct {
+	Key           string    `json:"thread_key"`
+	CommentCount  int64     `json:"comment_count"`
+	LastCommentAt time.Time `json:"last_comment_at"`
+}
+
+type ThreadListResponse struct {
+	Items []*ThreadResponse `json:"items"`
+}
+
+type ModerationResultResponse struct {
+	Updated int                `json:"updated"`
+	Items   []*CommentResponse `json:"items"`
+}
+
+type NotificationResponse struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	CommentID int64      `json:"comment_id"`
+	ThreadKey string     `json:"thread_key"`
+	Actor     string     `json:"actor"`
+	Excerpt   string     `json:"excerpt"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	Read      bool       `json:"read"`
+}
+
+type NotificationPageResponse struct {
+	Items       []*NotificationResponse `json:"items"`
+	UnreadCount int64                   `json:"unread_count"`
+	NextCursor  string                  `json:"next_cursor"`
+	PrevCursor  string                  `json:"prev_cursor"`
+}
+
+type WebhookResponse struct {
+	ID     int64    `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+	// Secret возвращается только при создании
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DeliveryAttemptResponse struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       *string   `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type DeliveryResponse struct {
+	ID             int64                      `json:"id"`
+	WebhookID      int64                      `json:"webhook_id"`
+	EventID        string                     `json:"event_id"`
+	EventType      string                     `json:"event_type"`
+	Status         string                     `json:"status"`
+	Attempts       int                        `json:"attempts"`
+	NextAttemptAt  *time.Time                 `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int                       `json:"last_status_code,omitempty"`
+	LastError      *string                    `json:"last_error,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	DeliveredAt    *time.Time                 `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage            `json:"payload,omitempty"`
+	AttemptLog     []*DeliveryAttemptResponse `json:"attempt_log,omitempty"`
+}
+
+type DeliveryPageResponse struct {
+	Items      []*DeliveryResponse `json:"items"`
+	NextCursor string              `json:"next_cursor"`
+	PrevCursor string              `json:"prev_cursor"`
+}
+
+// ProblemResponse — тело ошибки по RFC 7807 (application/problem+json)
+type ProblemResponse struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors — ошибки отдельных полей запроса, если он не прошёл проверку
+	Errors []FieldErrorResponse `json:"errors,omitempty"`
+}
+
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
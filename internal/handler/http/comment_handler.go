@@ -1,50 +1,63 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
 	"strconv"
 
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 	"github.com/yokitheyo/CommentTree/internal/domain"
 	"github.com/yokitheyo/CommentTree/internal/dto"
+	"github.com/yokitheyo/CommentTree/internal/pkg/cursor"
 )
 
 type CommentHandler struct {
 	service domain.CommentService
+	pages   PageLimits
 }
 
-func NewCommentHandler(service domain.CommentService) *CommentHandler {
-	return &CommentHandler{service: service}
+func NewCommentHandler(service domain.CommentService, pages PageLimits) *CommentHandler {
+	return &CommentHandler{service: service, pages: pages}
 }
 
 func (h *CommentHandler) RegisterRoutes(engine *ginext.Engine) {
 	group := engine.Group("/comments")
 	group.POST("", h.CreateComment)
 	group.GET("", h.GetComments)
+	group.GET("/:id", h.GetComment)
+	group.PUT("/:id", h.UpdateComment)
 	group.DELETE("/:id", h.DeleteComment)
+	group.GET("/:id/revisions", h.GetRevisions)
+	group.POST("/:id/restore", h.RestoreComment)
+	group.POST("/:id/lock", h.LockComment)
+	group.POST("/:id/unlock", h.UnlockComment)
+	group.POST("/:id/vote", h.VoteComment)
 	group.GET("/search", h.SearchComments)
+
+	engine.GET("/threads", h.ListThreads)
 }
 
 // CreateComment POST /comments
 func (h *CommentHandler) CreateComment(c *ginext.Context) {
 	var req dto.CreateCommentRequest
-	if err := c.BindJSON(&req); err != nil {
-		zlog.Logger.Warn().Err(err).Msg("invalid request body")
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid request"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	log := zlog.Logger.Debug().Str("author", req.Author)
+	log := zlog.Logger.Debug().Str("author", req.Author).Str("thread_key", req.ThreadKey)
 	if req.ParentID != nil {
 		log = log.Int64("parent_id", *req.ParentID)
 	}
 	log.Msg("CreateComment called")
 
-	comment, err := h.service.CreateComment(c, req.ParentID, req.Author, req.Content)
+	comment, err := h.service.CreateComment(c, req.ParentID, req.ThreadKey, req.Author, req.Content)
 	if err != nil {
 		zlog.Logger.Error().Err(err).Msg("CreateComment failed")
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": "failed to create comment"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -53,108 +66,483 @@ func (h *CommentHandler) CreateComment(c *ginext.Context) {
 
 }
 
-// GetComments GET /comments?parent={id}&limit=&offset=&sort=
+// GetComments GET /comments?thread=&parent={id}&limit=&offset=&after=&before=&sort=&hide_deleted=&format=
 func (h *CommentHandler) GetComments(c *ginext.Context) {
 	var parentID *int64
 	if parentStr := c.Query("parent"); parentStr != "" {
 		id, err := strconv.ParseInt(parentStr, 10, 64)
 		if err != nil {
 			zlog.Logger.Warn().Err(err).Str("parent", parentStr).Msg("invalid parent id")
-			c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid parent id"})
+			_ = c.Error(domain.Invalid("parent", "must be an integer"))
 			return
 		}
 		parentID = &id
 	}
 
-	limit := 10
-	if l := c.Query("limit"); l != "" {
-		if val, err := strconv.Atoi(l); err == nil {
-			limit = val
-		} else {
-			zlog.Logger.Warn().Err(err).Str("limit", l).Msg("invalid limit parameter, using default")
-		}
+	page, err := parsePageRequest(c, h.pages)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid pagination parameters")
+		_ = c.Error(err)
+		return
 	}
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		if val, err := strconv.Atoi(o); err == nil {
-			offset = val
-		} else {
-			zlog.Logger.Warn().Err(err).Str("offset", o).Msg("invalid offset parameter, using default")
-		}
+	sort, err := domain.ParseSort(c.Query("sort"))
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Str("sort", c.Query("sort")).Msg("invalid sort parameter")
+		_ = c.Error(domain.Invalid("sort", err.Error()))
+		return
+	}
+
+	hideDeleted, err := parseBoolQuery(c, "hide_deleted")
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid hide_deleted parameter")
+		_ = c.Error(err)
+		return
 	}
-	sort := c.Query("sort")
 
-	log := zlog.Logger.Debug().Int("limit", limit).Int("offset", offset).Str("sort", sort)
+	format, err := parseFormat(c)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid format parameter")
+		_ = c.Error(err)
+		return
+	}
+
+	log := zlog.Logger.Debug().Int("limit", page.Limit).Int("offset", page.Offset).Str("sort", sort)
 	if parentID != nil {
 		log = log.Int64("parent_id", *parentID)
 	}
 	log.Msg("GetComments called with parameters")
 
-	comments, err := h.service.GetThread(c, parentID, limit, offset, sort)
+	result, err := h.service.GetThread(c, domain.ThreadQuery{
+		ParentID:    parentID,
+		ThreadKey:   c.Query("thread"),
+		Sort:        sort,
+		Page:        page,
+		HideDeleted: hideDeleted,
+	})
 	if err != nil {
 		zlog.Logger.Error().Err(err).Msg("GetThread failed")
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": "failed to get comments"})
+		_ = c.Error(err)
+		return
+	}
+
+	resp := MapToCommentPageResponse(result)
+	if format == formatRaw {
+		withoutHTML(resp.Items)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetComment GET /comments/:id?depth=&format=
+func (h *CommentHandler) GetComment(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	depth := -1
+	if d := c.Query("depth"); d != "" {
+		val, err := strconv.Atoi(d)
+		if err != nil || val < 0 {
+			zlog.Logger.Warn().Str("depth", d).Msg("invalid depth parameter")
+			_ = c.Error(domain.Invalid("depth", "must be a non-negative integer"))
+			return
+		}
+		depth = val
+	}
+
+	format, err := parseFormat(c)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid format parameter")
+		_ = c.Error(err)
+		return
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Int("depth", depth).Msg("GetComment called")
+
+	result, err := h.service.GetComment(c, id, depth)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("GetComment failed")
+		_ = c.Error(err)
+		return
+	}
+
+	resp := MapToCommentContextResponse(result)
+	if format == formatRaw {
+		withoutHTML(append(resp.Ancestors, resp.Comment))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateComment PUT /comments/:id
+func (h *CommentHandler) UpdateComment(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateCommentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Str("editor", req.Editor).Msg("EditComment called")
+
+	comment, err := h.service.EditComment(c, id, req.Editor, req.Content)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("EditComment failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MapToCommentResponse(comment))
+}
+
+// VoteComment POST /comments/:id/vote
+func (h *CommentHandler) VoteComment(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.VoteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Int("value", req.Value).Msg("VoteComment called")
+
+	comment, err := h.service.VoteComment(c, id, req.Value)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("VoteComment failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MapToCommentResponse(comment))
+}
+
+// GetRevisions GET /comments/:id/revisions?from=&to=
+func (h *CommentHandler) GetRevisions(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(c, id)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("ListRevisions failed")
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, MapToCommentResponses(comments))
+	var revDiff *domain.RevisionDiff
+	fromStr, toStr := c.Query("from"), c.Query("to")
+	if fromStr != "" || toStr != "" {
+		from, errFrom := strconv.Atoi(fromStr)
+		to, errTo := strconv.Atoi(toStr)
+		if errFrom != nil || errTo != nil {
+			zlog.Logger.Warn().Str("from", fromStr).Str("to", toStr).Msg("invalid revision numbers")
+			verr := &domain.ValidationError{}
+			if errFrom != nil {
+				verr.Add("from", "must be a revision number")
+			}
+			if errTo != nil {
+				verr.Add("to", "must be a revision number")
+			}
+			_ = c.Error(verr)
+			return
+		}
+
+		revDiff, err = h.service.DiffRevisions(c, id, from, to)
+		if err != nil {
+			zlog.Logger.Warn().Err(err).Int64("comment_id", id).Msg("DiffRevisions failed")
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, MapToRevisionsResponse(id, revisions, revDiff))
 }
 
-// DeleteComment DELETE /comments/:id
+// DeleteComment DELETE /comments/:id?cascade=
 func (h *CommentHandler) DeleteComment(c *ginext.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	cascade, err := parseBoolQuery(c, "cascade")
 	if err != nil {
-		zlog.Logger.Warn().Err(err).Str("id", idStr).Msg("invalid id parameter")
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid id"})
+		zlog.Logger.Warn().Err(err).Msg("invalid cascade parameter")
+		_ = c.Error(err)
 		return
 	}
 
-	zlog.Logger.Debug().Int64("comment_id", id).Msg("DeleteThread called")
+	zlog.Logger.Debug().Int64("comment_id", id).Bool("cascade", cascade).Msg("DeleteThread called")
 
-	if err := h.service.DeleteThread(c, id); err != nil {
+	if err := h.service.DeleteThread(c, id, cascade); err != nil {
 		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("DeleteThread failed")
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": "failed to delete comment"})
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreComment POST /comments/:id/restore?cascade=
+func (h *CommentHandler) RestoreComment(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	cascade, err := parseBoolQuery(c, "cascade")
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid cascade parameter")
+		_ = c.Error(err)
+		return
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Bool("cascade", cascade).Msg("RestoreThread called")
+
+	if err := h.service.RestoreThread(c, id, cascade); err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("RestoreThread failed")
+		_ = c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
-// SearchComments GET /comments/search?query=&limit=&offset=
+// LockComment POST /comments/:id/lock
+func (h *CommentHandler) LockComment(c *ginext.Context) {
+	h.setLocked(c, true)
+}
+
+// UnlockComment POST /comments/:id/unlock
+func (h *CommentHandler) UnlockComment(c *ginext.Context) {
+	h.setLocked(c, false)
+}
+
+func (h *CommentHandler) setLocked(c *ginext.Context, locked bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Bool("locked", locked).Msg("LockComment called")
+
+	comment, err := h.service.LockComment(c, id, locked)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("LockComment failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MapToCommentResponse(comment))
+}
+
+// SearchComments GET /comments/search?query=&thread=&mode=&limit=&offset=&after=&before=&format=
 func (h *CommentHandler) SearchComments(c *ginext.Context) {
 	query := c.Query("query")
 	if query == "" {
 		zlog.Logger.Warn().Msg("search query is empty")
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "query cannot be empty"})
+		_ = c.Error(domain.Invalid("query", "required"))
+		return
+	}
+
+	mode, err := domain.ParseSearchMode(c.Query("mode"))
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Str("mode", c.Query("mode")).Msg("invalid search mode")
+		_ = c.Error(domain.Invalid("mode", err.Error()))
+		return
+	}
+
+	page, err := parsePageRequest(c, h.pages)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid pagination parameters in search")
+		_ = c.Error(err)
+		return
+	}
+
+	format, err := parseFormat(c)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid format parameter in search")
+		_ = c.Error(err)
+		return
+	}
+
+	zlog.Logger.Debug().Str("query", query).Str("mode", string(mode)).Int("limit", page.Limit).Int("offset", page.Offset).Msg("SearchComment called")
+
+	result, err := h.service.SearchComment(c, domain.SearchQuery{
+		Text:      query,
+		Mode:      mode,
+		ThreadKey: c.Query("thread"),
+		Page:      page,
+	})
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("query", query).Msg("SearchComment failed")
+		_ = c.Error(err)
+		return
+	}
+
+	resp := MapToSearchPageResponse(result)
+	if format == formatRaw {
+		for _, hit := range resp.Items {
+			withoutHTML([]*dto.CommentResponse{hit.Comment})
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListThreads GET /threads?limit=&offset=
+func (h *CommentHandler) ListThreads(c *ginext.Context) {
+	page, err := parsePageRequest(c, h.pages)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid pagination parameters for threads")
+		_ = c.Error(err)
+		return
+	}
+
+	threads, err := h.service.ListThreads(c, page.Limit, page.Offset)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("ListThreads failed")
+		_ = c.Error(err)
 		return
 	}
 
-	limit := 10
+	c.JSON(http.StatusOK, MapToThreadListResponse(threads))
+}
+
+// PageLimits — границы пагинации; нулевые MaxLimit и MaxOffset снимают верхнюю границу
+type PageLimits struct {
+	DefaultLimit int
+	MaxLimit     int
+	MaxOffset    int
+}
+
+// parsePageRequest читает limit/offset и курсоры after/before из query-параметров;
+// limit приводится к [1, MaxLimit], offset — к [0, MaxOffset]
+func parsePageRequest(c *ginext.Context, limits PageLimits) (domain.PageRequest, error) {
+	page := domain.PageRequest{Limit: limits.DefaultLimit}
+	if page.Limit <= 0 {
+		page.Limit = 10
+	}
+
+	verr := &domain.ValidationError{}
 	if l := c.Query("limit"); l != "" {
 		if val, err := strconv.Atoi(l); err == nil {
-			limit = val
+			page.Limit = clamp(val, 1, limits.MaxLimit)
 		} else {
-			zlog.Logger.Warn().Err(err).Str("limit", l).Msg("invalid limit parameter in search, using default")
+			verr.Add("limit", "must be an integer")
 		}
 	}
-	offset := 0
 	if o := c.Query("offset"); o != "" {
 		if val, err := strconv.Atoi(o); err == nil {
-			offset = val
+			page.Offset = clamp(val, 0, limits.MaxOffset)
 		} else {
-			zlog.Logger.Warn().Err(err).Str("offset", o).Msg("invalid offset parameter in search, using default")
+			verr.Add("offset", "must be an integer")
 		}
 	}
+	if err := verr.Err(); err != nil {
+		return page, err
+	}
+
+	after, err := cursor.Decode(c.Query("after"))
+	if err != nil {
+		return page, domain.Invalid("after", "invalid cursor")
+	}
+	before, err := cursor.Decode(c.Query("before"))
+	if err != nil {
+		return page, domain.Invalid("before", "invalid cursor")
+	}
+	if after != nil && before != nil {
+		return page, domain.Invalid("before", "cannot be used together with after")
+	}
+	page.After, page.Before = after, before
+
+	return page, nil
+}
+
+// clamp приводит v к [lo, hi]; hi <= 0 означает отсутствие верхней границы
+func clamp(v, lo, hi int) int {
+	if hi > 0 && v > hi {
+		v = hi
+	}
+	return max(v, lo)
+}
+
+const (
+	formatRaw  = "raw"
+	formatHTML = "html"
+)
+
+// parseFormat читает format=raw|html: raw отдаёт только исходный текст, html добавляет content_html
+func parseFormat(c *ginext.Context) (string, error) {
+	switch f := c.DefaultQuery("format", formatRaw); f {
+	case formatRaw, formatHTML:
+		return f, nil
+	default:
+		return "", domain.Invalid("format", fmt.Sprintf("must be %s or %s, got %q", formatRaw, formatHTML, f))
+	}
+}
 
-	zlog.Logger.Debug().Str("query", query).Int("limit", limit).Int("offset", offset).Msg("SearchComment called")
+// withoutHTML убирает content_html из дерева ответов
+func withoutHTML(items []*dto.CommentResponse) {
+	for _, item := range items {
+		item.ContentHTML = ""
+		withoutHTML(item.Children)
+	}
+}
 
-	comments, err := h.service.SearchComment(c, query, limit, offset)
+// parseBoolQuery читает необязательный булев query-параметр; отсутствие означает false
+func parseBoolQuery(c *ginext.Context, name string) (bool, error) {
+	v := c.Query(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Str("query", query).Msg("SearchComment failed")
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": "search failed"})
-		return
+		return false, domain.Invalid(name, "must be a boolean")
+	}
+	return b, nil
+}
+
+// bindJSON разбирает тело запроса в req; при ошибке передаёт её в ErrorMiddleware и возвращает false
+func bindJSON(c *ginext.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid request body")
+		_ = c.Error(bodyError(err))
+		return false
 	}
+	return true
+}
 
-	c.JSON(http.StatusOK, comments)
+// bodyError описывает ошибку разбора JSON; для значения не того типа указывает поле
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return domain.BodyTooLarge(tooLarge.Limit)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return domain.Invalid(typeErr.Field, "must be "+jsonTypeName(typeErr.Type))
+	}
+	return domain.Invalid("body", "must be a valid JSON object")
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	}
+	return "a number"
 }
@@ -3,6 +3,8 @@ package http
 import (
 	"github.com/yokitheyo/CommentTree/internal/domain"
 	"github.com/yokitheyo/CommentTree/internal/dto"
+	"github.com/yokitheyo/CommentTree/internal/pkg/cursor"
+	"github.com/yokitheyo/CommentTree/internal/pkg/markdown"
 )
 
 func MapToCommentResponse(c *domain.Comment) *dto.CommentResponse {
@@ -16,17 +18,40 @@ func MapToCommentResponse(c *domain.Comment) *dto.CommentResponse {
 	}
 
 	return &dto.CommentResponse{
-		ID:        c.ID,
-		ParentID:  c.ParentID,
-		Content:   c.Content,
-		Author:    c.Author,
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
-		Deleted:   c.Deleted,
-		Children:  children,
+		ID:               c.ID,
+		ParentID:         c.ParentID,
+		ThreadKey:        c.ThreadKey,
+		Content:          c.Content,
+		ContentHTML:      contentHTML(c),
+		Author:           c.Author,
+		AuthorVerified:   c.AuthorSubject != "",
+		CreatedAt:        c.CreatedAt,
+		UpdatedAt:        c.UpdatedAt,
+		EditedBy:         c.EditedBy,
+		EditedAt:         c.EditedAt,
+		Deleted:          c.Deleted,
+		Upvotes:          c.Upvotes,
+		Downvotes:        c.Downvotes,
+		Score:            c.Score,
+		Locked:           c.Locked,
+		Status:           string(c.Status),
+		ModerationReason: c.ModerationReason,
+		ModeratedBy:      c.ModeratedBy,
+		ModeratedAt:      c.ModeratedAt,
+		ManagementToken:  c.ManagementToken,
+		Children:         children,
 	}
 }
 
+// contentHTML отдаёт сохранённый HTML; комментарии, записанные до рендеринга Markdown,
+// рендерятся на лету
+func contentHTML(c *domain.Comment) string {
+	if c.ContentHTML == "" && c.Content != "" {
+		return markdown.Render(c.Content)
+	}
+	return c.ContentHTML
+}
+
 func MapToCommentResponses(list []*domain.Comment) []*dto.CommentResponse {
 	out := make([]*dto.CommentResponse, 0, len(list))
 	for _, c := range list {
@@ -34,3 +59,187 @@ func MapToCommentResponses(list []*domain.Comment) []*dto.CommentResponse {
 	}
 	return out
 }
+
+func MapToCommentContextResponse(cc *domain.CommentContext) *dto.CommentContextResponse {
+	return &dto.CommentContextResponse{
+		Comment:   MapToCommentResponse(cc.Comment),
+		Ancestors: MapToCommentResponses(cc.Ancestors),
+		Depth:     cc.Depth,
+	}
+}
+
+func MapToCommentEventResponse(e *domain.CommentEvent) *dto.CommentEventResponse {
+	return &dto.CommentEventResponse{
+		Type:    string(e.Type),
+		Comment: MapToCommentResponse(e.Comment),
+		Subtree: e.Subtree,
+		At:      e.At,
+	}
+}
+
+func MapToSearchHitResponses(hits []*domain.SearchHit) []*dto.SearchHitResponse {
+	out := make([]*dto.SearchHitResponse, 0, len(hits))
+	for _, h := range hits {
+		matched := h.MatchedFields
+		if matched == nil {
+			matched = []string{}
+		}
+		out = append(out, &dto.SearchHitResponse{
+			Comment:       MapToCommentResponse(h.Comment),
+			Snippet:       h.Snippet,
+			Rank:          h.Rank,
+			MatchedFields: matched,
+		})
+	}
+	return out
+}
+
+func MapToCommentPageResponse(p *domain.CommentPage) *dto.CommentPageResponse {
+	return &dto.CommentPageResponse{
+		Items:      MapToCommentResponses(p.Items),
+		NextCursor: cursor.Encode(p.Next),
+		PrevCursor: cursor.Encode(p.Prev),
+	}
+}
+
+func MapToSearchPageResponse(p *domain.SearchPage) *dto.SearchPageResponse {
+	return &dto.SearchPageResponse{
+		Items:      MapToSearchHitResponses(p.Hits),
+		NextCursor: cursor.Encode(p.Next),
+		PrevCursor: cursor.Encode(p.Prev),
+	}
+}
+
+func MapToRevisionsResponse(commentID int64, revisions []*domain.Revision, d *domain.RevisionDiff) *dto.RevisionsResponse {
+	out := &dto.RevisionsResponse{
+		CommentID: commentID,
+		Revisions: make([]*dto.RevisionResponse, 0, len(revisions)),
+	}
+	for _, r := range revisions {
+		out.Revisions = append(out.Revisions, &dto.RevisionResponse{
+			Number:    r.Number,
+			Content:   r.Content,
+			Editor:    r.Editor,
+			CreatedAt: r.CreatedAt,
+			Current:   r.Current,
+		})
+	}
+
+	if d != nil {
+		out.Diff = &dto.RevisionDiffResponse{
+			From:   d.From,
+			To:     d.To,
+			Chunks: make([]*dto.DiffChunkResponse, 0, len(d.Chunks)),
+		}
+		for _, ch := range d.Chunks {
+			out.Diff.Chunks = append(out.Diff.Chunks, &dto.DiffChunkResponse{Op: ch.Op, Text: ch.Text})
+		}
+	}
+
+	return out
+}
+
+func MapToThreadListResponse(threads []*domain.ThreadSummary) *dto.ThreadListResponse {
+	out := &dto.ThreadListResponse{Items: make([]*dto.ThreadResponse, 0, len(threads))}
+	for _, t := range threads {
+		out.Items = append(out.Items, &dto.ThreadResponse{
+			Key:           t.Key,
+			CommentCount:  t.CommentCount,
+			LastCommentAt: t.LastCommentAt,
+		})
+	}
+	return out
+}
+
+func MapToNotificationPageResponse(p *domain.NotificationPage) *dto.NotificationPageResponse {
+	out := &dto.NotificationPageResponse{
+		Items:       make([]*dto.NotificationResponse, 0, len(p.Items)),
+		UnreadCount: p.UnreadCount,
+		NextCursor:  cursor.Encode(p.Next),
+		PrevCursor:  cursor.Encode(p.Prev),
+	}
+	for _, n := range p.Items {
+		out.Items = append(out.Items, &dto.NotificationResponse{
+			ID:        n.ID,
+			Kind:      string(n.Kind),
+			CommentID: n.CommentID,
+			ThreadKey: n.ThreadKey,
+			Actor:     n.Actor,
+			Excerpt:   n.Excerpt,
+			CreatedAt: n.CreatedAt,
+			ReadAt:    n.ReadAt,
+			Read:      n.ReadAt != nil,
+		})
+	}
+	return out
+}
+
+func MapToWebhookResponse(w *domain.Webhook) *dto.WebhookResponse {
+	events := w.Events
+	if events == nil {
+		events = []string{}
+	}
+	return &dto.WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func MapToWebhookResponses(list []*domain.Webhook) []*dto.WebhookResponse {
+	out := make([]*dto.WebhookResponse, 0, len(list))
+	for _, w := range list {
+		out = append(out, MapToWebhookResponse(w))
+	}
+	return out
+}
+
+// MapToDeliveryResponse не включает тело события; его добавляет обработчик карточки доставки
+func MapToDeliveryResponse(d *domain.WebhookDelivery) *dto.DeliveryResponse {
+	resp := &dto.DeliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == domain.DeliveryPending {
+		next := d.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+	return resp
+}
+
+func MapToDeliveryPageResponse(p *domain.DeliveryPage) *dto.DeliveryPageResponse {
+	out := &dto.DeliveryPageResponse{
+		Items:      make([]*dto.DeliveryResponse, 0, len(p.Items)),
+		NextCursor: cursor.Encode(p.Next),
+		PrevCursor: cursor.Encode(p.Prev),
+	}
+	for _, d := range p.Items {
+		out.Items = append(out.Items, MapToDeliveryResponse(d))
+	}
+	return out
+}
+
+func MapToDeliveryAttemptResponses(list []*domain.DeliveryAttempt) []*dto.DeliveryAttemptResponse {
+	out := make([]*dto.DeliveryAttemptResponse, 0, len(list))
+	for _, a := range list {
+		out = append(out, &dto.DeliveryAttemptResponse{
+			Attempt:     a.Attempt,
+			StatusCode:  a.StatusCode,
+			Error:       a.Error,
+			DurationMS:  a.Duration.Milliseconds(),
+			AttemptedAt: a.AttemptedAt,
+		})
+	}
+	return out
+}
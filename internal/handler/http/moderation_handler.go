@@ -0,0 +1,91 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/dto"
+	"github.com/yokitheyo/CommentTree/internal/handler/middleware"
+)
+
+type ModerationHandler struct {
+	service domain.ModerationService
+	pages   PageLimits
+}
+
+func NewModerationHandler(service domain.ModerationService, pages PageLimits) *ModerationHandler {
+	return &ModerationHandler{service: service, pages: pages}
+}
+
+func (h *ModerationHandler) RegisterRoutes(engine *ginext.Engine) {
+	group := engine.Group("/moderation", middleware.RequireModerator())
+	group.GET("/queue", h.GetQueue)
+	group.POST("/approve", h.Approve)
+	group.POST("/reject", h.Reject)
+	// Модель спам-фильтра общая для всех обсуждений — модератору одного обсуждения её не переобучать
+	group.POST("/spam/train", middleware.RequireGlobalModerator(), h.TrainSpamFilter)
+}
+
+// GetQueue GET /moderation/queue?thread=&limit=&offset=&after=&before=
+func (h *ModerationHandler) GetQueue(c *ginext.Context) {
+	page, err := parsePageRequest(c, h.pages)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid pagination parameters for moderation queue")
+		_ = c.Error(err)
+		return
+	}
+
+	result, err := h.service.ListQueue(c, c.Query("thread"), page)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("ListQueue failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MapToCommentPageResponse(result))
+}
+
+// Approve POST /moderation/approve
+func (h *ModerationHandler) Approve(c *ginext.Context) {
+	h.decide(c, domain.StatusApproved)
+}
+
+// Reject POST /moderation/reject
+func (h *ModerationHandler) Reject(c *ginext.Context) {
+	h.decide(c, domain.StatusRejected)
+}
+
+func (h *ModerationHandler) decide(c *ginext.Context, decision domain.ModerationStatus) {
+	var req dto.ModerationDecisionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	zlog.Logger.Debug().Int("count", len(req.IDs)).Str("decision", string(decision)).Msg("Moderate called")
+
+	comments, err := h.service.Moderate(c, req.IDs, decision, req.Reason)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("decision", string(decision)).Msg("Moderate failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &dto.ModerationResultResponse{
+		Updated: len(comments),
+		Items:   MapToCommentResponses(comments),
+	})
+}
+
+// TrainSpamFilter POST /moderation/spam/train
+func (h *ModerationHandler) TrainSpamFilter(c *ginext.Context) {
+	samples, err := h.service.TrainSpamFilter(c)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("TrainSpamFilter failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ginext.H{"samples": samples})
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/dto"
+)
+
+type NotificationHandler struct {
+	service domain.NotificationService
+	pages   PageLimits
+}
+
+func NewNotificationHandler(service domain.NotificationService, pages PageLimits) *NotificationHandler {
+	return &NotificationHandler{service: service, pages: pages}
+}
+
+func (h *NotificationHandler) RegisterRoutes(engine *ginext.Engine) {
+	group := engine.Group("/notifications")
+	group.GET("", h.ListNotifications)
+	group.POST("/read", h.MarkRead)
+}
+
+// ListNotifications GET /notifications?unread=&limit=&offset=&after=&before=
+func (h *NotificationHandler) ListNotifications(c *ginext.Context) {
+	page, err := parsePageRequest(c, h.pages)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid pagination parameters for notifications")
+		_ = c.Error(err)
+		return
+	}
+	unread, err := parseBoolQuery(c, "unread")
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid unread parameter")
+		_ = c.Error(err)
+		return
+	}
+
+	result, err := h.service.ListNotifications(c, unread, page)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("ListNotifications failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MapToNotificationPageResponse(result))
+}
+
+// MarkRead POST /notifications/read
+func (h *NotificationHandler) MarkRead(c *ginext.Context) {
+	var req dto.MarkNotificationsReadRequest
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &req) {
+			return
+		}
+	}
+
+	updated, err := h.service.MarkNotificationsRead(c, req.IDs)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("MarkNotificationsRead failed")
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ginext.H{"updated": updated})
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// streamRetry — через сколько браузер переподключается после обрыва
+const streamRetry = 3 * time.Second
+
+type StreamHandler struct {
+	service   domain.CommentService
+	heartbeat time.Duration
+}
+
+// NewStreamHandler создаёт обработчик живой ленты; heartbeat — период
+// комментариев-пингов, которые не дают прокси закрыть простаивающее соединение.
+func NewStreamHandler(service domain.CommentService, heartbeat time.Duration) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &StreamHandler{service: service, heartbeat: heartbeat}
+}
+
+func (h *StreamHandler) RegisterRoutes(engine *ginext.Engine) {
+	engine.GET("/comments/stream", h.StreamComments)
+}
+
+// StreamComments GET /comments/stream?thread=&parent=&last_event_id=
+// Отдаёт text/event-stream; после обрыва браузер сам присылает Last-Event-ID.
+func (h *StreamHandler) StreamComments(c *ginext.Context) {
+	q := domain.StreamQuery{ThreadKey: c.Query("thread")}
+
+	if parentStr := c.Query("parent"); parentStr != "" {
+		id, err := strconv.ParseInt(parentStr, 10, 64)
+		if err != nil {
+			zlog.Logger.Warn().Err(err).Str("parent", parentStr).Msg("invalid parent id")
+			_ = c.Error(domain.Invalid("parent", "must be an integer"))
+			return
+		}
+		q.ParentID = &id
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID != "" {
+		seq, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || seq < 0 {
+			zlog.Logger.Warn().Str("last_event_id", lastID).Msg("invalid last event id")
+			_ = c.Error(domain.Invalid("last_event_id", "must be a non-negative integer"))
+			return
+		}
+		q.LastEventID = seq
+	}
+
+	events, err := h.service.StreamComments(c, q)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("StreamComments failed")
+		_ = c.Error(err)
+		return
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// nginx иначе копит ответ в буфере и события приходят пачками
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	w.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+		case e, ok := <-events:
+			if !ok {
+				// Подписку сняли (клиент не успевал читать) — пусть переподключится
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				zlog.Logger.Debug().Err(err).Msg("stream: write failed")
+				return
+			}
+			w.Flush()
+		}
+	}
+}
+
+func writeEvent(w io.Writer, e *domain.CommentEvent) error {
+	data, err := json.Marshal(MapToCommentEventResponse(e))
+	if err != nil {
+		return fmt.Errorf("marshal event seq=%d: %w", e.Seq, err)
+	}
+	// Без id браузер сохранит прежний Last-Event-ID
+	if e.Seq > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", e.Seq); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return err
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/dto"
+	"github.com/yokitheyo/CommentTree/internal/handler/middleware"
+)
+
+type WebhookHandler struct {
+	service domain.WebhookService
+	pages   PageLimits
+}
+
+func NewWebhookHandler(service domain.WebhookService, pages PageLimits) *WebhookHandler {
+	return &WebhookHandler{service: service, pages: pages}
+}
+
+func (h *WebhookHandler) RegisterRoutes(engine *ginext.Engine) {
+	group := engine.Group("/admin/webhooks", middleware.RequireAdmin())
+	group.GET("", h.ListWebhooks)
+	group.POST("", h.CreateWebhook)
+	group.GET("/:id", h.GetWebhook)
+	group.PUT("/:id", h.UpdateWebhook)
+	group.DELETE("/:id", h.DeleteWebhook)
+	group.GET("/:id/deliveries", h.ListDeliveries)
+	group.GET("/:id/deliveries/:delivery_id", h.GetDelivery)
+	group.POST("/:id/deliveries/:delivery_id/replay", h.ReplayDelivery)
+}
+
+// ListWebhooks GET /admin/webhooks
+func (h *WebhookHandler) ListWebhooks(c *ginext.Context) {
+	hooks, err := h.service.ListWebhooks(c)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("ListWebhooks failed")
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ginext.H{"items": MapToWebhookResponses(hooks)})
+}
+
+// CreateWebhook POST /admin/webhooks
+func (h *WebhookHandler) CreateWebhook(c *ginext.Context) {
+	var req dto.WebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	w, err := h.service.CreateWebhook(c, webhookFromRequest(&req))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("CreateWebhook failed")
+		_ = c.Error(err)
+		return
+	}
+
+	resp := MapToWebhookResponse(w)
+	resp.Secret = w.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetWebhook GET /admin/webhooks/:id
+func (h *WebhookHandler) GetWebhook(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	w, err := h.service.GetWebhook(c, id)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("GetWebhook failed")
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, MapToWebhookResponse(w))
+}
+
+// UpdateWebhook PUT /admin/webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	w := webhookFromRequest(&req)
+	w.ID = id
+	w, err := h.service.UpdateWebhook(c, w)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("UpdateWebhook failed")
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, MapToWebhookResponse(w))
+}
+
+// DeleteWebhook DELETE /admin/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(c, id); err != nil {
+		zlog.Logger.Error().Err(err).Msg("DeleteWebhook failed")
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries GET /admin/webhooks/:id/deliveries?status=&limit=&offset=&after=&before=
+func (h *WebhookHandler) ListDeliveries(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(c, h.pages)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("invalid pagination parameters for deliveries")
+		_ = c.Error(err)
+		return
+	}
+
+	result, err := h.service.ListDeliveries(c, id, domain.DeliveryStatus(c.Query("status")), page)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("ListDeliveries failed")
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, MapToDeliveryPageResponse(result))
+}
+
+// GetDelivery GET /admin/webhooks/:id/deliveries/:delivery_id
+func (h *WebhookHandler) GetDelivery(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := paramID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	d, attempts, err := h.service.GetDelivery(c, id, deliveryID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("GetDelivery failed")
+		_ = c.Error(err)
+		return
+	}
+
+	resp := MapToDeliveryResponse(d)
+	resp.Payload = d.Payload
+	resp.AttemptLog = MapToDeliveryAttemptResponses(attempts)
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReplayDelivery POST /admin/webhooks/:id/deliveries/:delivery_id/replay
+func (h *WebhookHandler) ReplayDelivery(c *ginext.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := paramID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	d, err := h.service.ReplayDelivery(c, id, deliveryID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("ReplayDelivery failed")
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, MapToDeliveryResponse(d))
+}
+
+func webhookFromRequest(req *dto.WebhookRequest) *domain.Webhook {
+	w := &domain.Webhook{URL: req.URL, Events: req.Events, Secret: req.Secret, Active: true}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+	return w
+}
+
+func paramID(c *ginext.Context, name string) (int64, bool) {
+	raw := c.Param(name)
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		zlog.Logger.Warn().Err(err).Str(name, raw).Msg("invalid id parameter")
+		_ = c.Error(domain.Invalid(name, "must be a positive integer"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/pkg/jwt"
+)
+
+// ClaimNames — утверждения токена, из которых берутся имя, роли и модерируемые обсуждения
+type ClaimNames struct {
+	Name    string
+	Roles   string
+	Threads string
+}
+
+// AuthMiddleware проверяет Bearer-токен и кладёт пользователя в контекст запроса.
+// Запрос без токена проходит анонимно; решать, пускать ли анонимов, будет usecase.
+// Имя пользователя берётся из claims.Name, затем из name и sub.
+func AuthMiddleware(verifier *jwt.Verifier, claims ClaimNames) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			abortWithError(c, &domain.Error{Kind: domain.ErrUnauthorized, Message: "bearer token required"})
+			return
+		}
+
+		verified, err := verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			zlog.Logger.Warn().Err(err).Msg("token verification failed")
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			abortWithError(c, &domain.Error{Kind: domain.ErrUnauthorized, Message: "invalid token"})
+			return
+		}
+
+		p := &domain.Principal{
+			Subject: verified.Subject,
+			Name:    firstNonEmpty(verified.String(claims.Name), verified.String("name"), verified.Subject),
+			Role:    domain.HighestRole(verified.Strings(claims.Roles)),
+			Threads: verified.Strings(claims.Threads),
+			Claims:  verified.Raw,
+		}
+		if p.Name == "" {
+			abortWithError(c, &domain.Error{Kind: domain.ErrUnauthorized, Message: "token has no subject"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// BodyLimitMiddleware ограничивает тело запроса maxBytes байтами: заявленное в Content-Length
+// длинное тело отклоняется сразу, остальное обрывается при чтении и отклоняется обработчиком
+func BodyLimitMiddleware(maxBytes int64) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		if c.Request.ContentLength > maxBytes {
+			abortWithError(c, domain.BodyTooLarge(maxBytes))
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+		c.Next()
+	}
+}
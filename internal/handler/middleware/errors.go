@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/dto"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	// problemTypePrefix — к нему добавляется категория ошибки, например urn:commenttree:problem:not-found
+	problemTypePrefix = "urn:commenttree:problem:"
+)
+
+// problemKinds сопоставляет категории ошибок со статусами; побеждает первая подходящая
+var problemKinds = []struct {
+	kind   error
+	status int
+	name   string
+}{
+	{domain.ErrValidation, http.StatusBadRequest, "validation"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrNotFound, http.StatusNotFound, "not-found"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+	{domain.ErrTooLarge, http.StatusRequestEntityTooLarge, "too-large"},
+	{domain.ErrRateLimited, http.StatusTooManyRequests, "rate-limited"},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+}
+
+// ErrorMiddleware отвечает на ошибку, переданную обработчиком или middleware через c.Error,
+// телом application/problem+json. Стоит первым, чтобы видеть ошибки всей цепочки.
+func ErrorMiddleware() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		problem := NewProblem(c.Errors.Last().Err, c.Request.URL.Path)
+		if problem.Status >= http.StatusInternalServerError {
+			zlog.Logger.Error().Err(c.Errors.Last().Err).Str("path", c.Request.URL.Path).Msg("request failed")
+		}
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// NewProblem описывает ошибку для клиента. В detail попадает только сообщение доменной
+// ошибки: контекст из цепочки обёрток и ошибки без категории наружу не раскрываются.
+func NewProblem(err error, instance string) *dto.ProblemResponse {
+	problem := &dto.ProblemResponse{
+		Type:     problemTypePrefix + "internal",
+		Status:   http.StatusInternalServerError,
+		Detail:   "internal server error",
+		Instance: instance,
+	}
+	for _, k := range problemKinds {
+		if errors.Is(err, k.kind) {
+			problem.Type, problem.Status, problem.Detail = problemTypePrefix+k.name, k.status, k.kind.Error()
+			break
+		}
+	}
+	problem.Title = http.StatusText(problem.Status)
+
+	var (
+		validation *domain.ValidationError
+		forbidden  *domain.ForbiddenError
+		domainErr  *domain.Error
+	)
+	switch {
+	case errors.As(err, &validation):
+		problem.Detail = validation.Error()
+		for _, f := range validation.Fields {
+			problem.Errors = append(problem.Errors, dto.FieldErrorResponse{Field: f.Field, Message: f.Message})
+		}
+	case errors.As(err, &forbidden):
+		problem.Detail = forbidden.Error()
+	case errors.As(err, &domainErr):
+		problem.Detail = domainErr.Message
+	}
+	return problem
+}
+
+// abortWithError прерывает цепочку; ответ пишет ErrorMiddleware
+func abortWithError(c *ginext.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
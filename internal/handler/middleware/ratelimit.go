@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/infrastructure/ratelimit"
+	"golang.org/x/text/unicode/norm"
+)
+
+// RateLimits — лимиты на запись по каждому ключу; нулевой лимит не проверяется
+type RateLimits struct {
+	IP     ratelimit.Limit
+	Author ratelimit.Limit
+	Thread ratelimit.Limit
+}
+
+// ThreadLookup возвращает ключ обсуждения, к которому относится комментарий
+type ThreadLookup func(ctx context.Context, commentID int64) (string, error)
+
+// writeBody — поля тела запроса, по которым считается лимит обсуждения
+type writeBody struct {
+	ThreadKey string `json:"thread_key"`
+	ParentID  *int64 `json:"parent_id"`
+}
+
+// RateLimitMiddleware ограничивает запросы на запись (POST, PUT, PATCH, DELETE)
+// корзинами по IP, автору и обсуждению. Корзина автора есть только у аутентифицированных
+// пользователей: имя из тела запроса ничего не доказывает, и по нему можно было бы
+// исчерпать чужой лимит. Обсуждение ответа определяется по родителю через threadOf,
+// а не по телу запроса. IP берётся с учётом доверенных прокси (server.trusted_proxies).
+// Модераторы не ограничиваются. При ошибке хранилища запрос пропускается: лимитер
+// не должен ронять запись.
+func RateLimitMiddleware(store ratelimit.Store, limits RateLimits, threadOf ThreadLookup) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if domain.ViewerFromContext(c.Request.Context()).IsModerator() {
+			c.Next()
+			return
+		}
+
+		candidates := []ratelimit.Bucket{{Key: "ip:" + c.ClientIP(), Limit: limits.IP}}
+		if p, ok := domain.PrincipalFromContext(c.Request.Context()); ok && p.Name != "" {
+			candidates = append(candidates, ratelimit.Bucket{Key: "author:" + p.Name, Limit: limits.Author})
+		}
+		if limits.Thread.Enabled() {
+			if thread := threadBucketKey(c.Request.Context(), peekWriteBody(c), threadOf); thread != "" {
+				candidates = append(candidates, ratelimit.Bucket{Key: "thread:" + thread, Limit: limits.Thread})
+			}
+		}
+
+		buckets := candidates[:0]
+		for _, b := range candidates {
+			if b.Limit.Enabled() {
+				buckets = append(buckets, b)
+			}
+		}
+		if len(buckets) == 0 {
+			c.Next()
+			return
+		}
+
+		results, err := store.Take(c.Request.Context(), buckets)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msg("rate limit store failed")
+			c.Next()
+			return
+		}
+
+		var tightest *ratelimit.Result
+		for i := range results {
+			res := &results[i]
+			if !res.Allowed {
+				zlog.Logger.Warn().Str("key", buckets[i].Key).Dur("retry_after", res.RetryAfter).Msg("rate limit exceeded")
+			}
+			if tightest == nil || tighter(res, tightest) {
+				tightest = res
+			}
+		}
+
+		h := c.Writer.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(tightest.Reset)))
+
+		if !tightest.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(tightest.RetryAfter)))
+			abortWithError(c, domain.ErrRateLimited)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tighter сообщает, что по заголовкам клиенту важнее видеть a, чем b: отказ важнее разрешения,
+// из отказов — тот, что ждать дольше, из разрешений — тот, где осталось меньше
+func tighter(a, b *ratelimit.Result) bool {
+	switch {
+	case a.Allowed != b.Allowed:
+		return !a.Allowed
+	case !a.Allowed:
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+// peekWriteBody читает JSON-тело и возвращает его на место для обработчика
+func peekWriteBody(c *ginext.Context) writeBody {
+	var body writeBody
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return body
+	}
+
+	raw, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		// Ошибку чтения (например, превышение размера тела) обработчик получит при разборе
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(raw), errReader{err}))
+		return body
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+
+	// Ошибку разбора оставляем обработчику: он ответит 400
+	_ = json.Unmarshal(raw, &body)
+	return body
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// threadBucketKey — ключ обсуждения, в которое пишет запрос, или пустая строка.
+// Для ответа обсуждение берётся у родителя, а thread_key из тела не учитывается:
+// иначе, меняя его или parent_id, можно было бы получать новые корзины в том же обсуждении.
+// Ключ нормализуется так же, как при записи комментария.
+func threadBucketKey(ctx context.Context, b writeBody, threadOf ThreadLookup) string {
+	if b.ParentID == nil {
+		return norm.NFC.String(strings.TrimSpace(b.ThreadKey))
+	}
+
+	key, err := threadOf(ctx, *b.ParentID)
+	if err != nil {
+		// Несуществующего родителя отклонит обработчик; сбой хранилища, как и у лимитера, не роняет запись
+		if !errors.Is(err, domain.ErrNotFound) {
+			zlog.Logger.Error().Err(err).Int64("parent_id", *b.ParentID).Msg("rate limit: thread lookup failed")
+		}
+		return ""
+	}
+	return key
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
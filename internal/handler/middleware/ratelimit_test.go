@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+func TestThreadBucketKey(t *testing.T) {
+	threads := map[int64]string{1: "post-1", 2: "post-1", 3: "post-2"}
+	threadOf := func(_ context.Context, id int64) (string, error) {
+		if id == 99 {
+			return "", errors.New("connection refused")
+		}
+		key, ok := threads[id]
+		if !ok {
+			return "", fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
+		}
+		return key, nil
+	}
+	parent := func(id int64) *int64 { return &id }
+
+	tests := []struct {
+		name string
+		body writeBody
+		want string
+	}{
+		{"root comment", writeBody{ThreadKey: "post-1"}, "post-1"},
+		{"root key is trimmed", writeBody{ThreadKey: "  post-1 "}, "post-1"},
+		{"root key is normalized", writeBody{ThreadKey: "cafe\u0301"}, "caf\u00e9"},
+		{"reply takes the parent's thread", writeBody{ParentID: parent(1)}, "post-1"},
+		{"replies to different comments share the thread", writeBody{ParentID: parent(2)}, "post-1"},
+		{"thread_key in a reply is ignored", writeBody{ParentID: parent(3), ThreadKey: "spoofed"}, "post-2"},
+		{"unknown parent", writeBody{ParentID: parent(42), ThreadKey: "post-1"}, ""},
+		{"lookup failure", writeBody{ParentID: parent(99), ThreadKey: "post-1"}, ""},
+		{"no thread", writeBody{}, ""},
+		{"blank thread", writeBody{ThreadKey: "  "}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := threadBucketKey(context.Background(), tt.body, threadOf); got != tt.want {
+				t.Fatalf("threadBucketKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+const (
+	ModeratorKeyHeader = "X-Moderator-Key"
+	AdminKeyHeader     = "X-Admin-Key"
+	// ManagementTokenHeader — токен, которым анонимный автор правит и удаляет свой комментарий
+	ManagementTokenHeader = "X-Management-Token"
+)
+
+// ViewerKeys — статические ключи, повышающие роль читателя без SSO; пустой ключ отключён
+type ViewerKeys struct {
+	Moderator string
+	Admin     string
+}
+
+// ViewerMiddleware кладёт в контекст запроса читателя. Имя и роль берутся только из
+// проверенного токена; без него читатель анонимен, и свой комментарий он может
+// править лишь по токену управления. Совпадение X-Moderator-Key с настроенным ключом
+// делает читателя модератором всех обсуждений, X-Admin-Key — администратором.
+func ViewerMiddleware(keys ViewerKeys) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		v := domain.Viewer{Role: domain.RoleAnonymous}
+		if p, ok := domain.PrincipalFromContext(c.Request.Context()); ok {
+			v = domain.Viewer{Name: p.Name, Subject: p.Subject, Role: p.Role, Threads: p.Threads}
+		}
+
+		if keyMatches(c.GetHeader(ModeratorKeyHeader), keys.Moderator) && !v.ModeratesAll() {
+			v.Role, v.Threads = domain.RoleModerator, []string{domain.AllThreads}
+		}
+		if keyMatches(c.GetHeader(AdminKeyHeader), keys.Admin) {
+			v.Role, v.Threads = domain.RoleAdmin, nil
+		}
+
+		v.ManagementToken = strings.TrimSpace(c.GetHeader(ManagementTokenHeader))
+
+		c.Request = c.Request.WithContext(domain.WithViewer(c.Request.Context(), v))
+		c.Next()
+	}
+}
+
+// RequireModerator пропускает только запросы модераторов
+func RequireModerator() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		if !domain.ViewerFromContext(c.Request.Context()).IsModerator() {
+			abortWithError(c, &domain.Error{Kind: domain.ErrForbidden, Message: "moderator access required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireGlobalModerator пропускает только модераторов всех обсуждений и администраторов
+func RequireGlobalModerator() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		if !domain.ViewerFromContext(c.Request.Context()).ModeratesAll() {
+			abortWithError(c, &domain.Error{Kind: domain.ErrForbidden, Message: "moderator access to all threads required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin пропускает только запросы администраторов
+func RequireAdmin() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		if domain.ViewerFromContext(c.Request.Context()).Role != domain.RoleAdmin {
+			abortWithError(c, &domain.Error{Kind: domain.ErrForbidden, Message: "admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func keyMatches(got, want string) bool {
+	return want != "" && got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
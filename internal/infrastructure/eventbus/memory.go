@@ -0,0 +1,36 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// MemoryBus доставляет события только внутри процесса, синхронно в Publish.
+// Годится для одного экземпляра и для тестов.
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers []domain.EventHandler
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, e *domain.CommentEvent) error {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(h domain.EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
@@ -0,0 +1,198 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+const (
+	// maxPayload — предел NOTIFY (8000 байт) с запасом на служебные поля
+	maxPayload = 7500
+	// pingInterval — как часто проверять соединение слушателя в тишине
+	pingInterval = 90 * time.Second
+)
+
+// CommentLoader перечитывает комментарий, не поместившийся в уведомление
+type CommentLoader func(ctx context.Context, id int64) (*domain.Comment, error)
+
+// message — событие в том виде, в каком оно уходит через NOTIFY
+type message struct {
+	ID      string                  `json:"id"`
+	Seq     int64                   `json:"seq,omitempty"`
+	Type    domain.CommentEventType `json:"type"`
+	Comment *domain.Comment         `json:"comment,omitempty"`
+	// CommentID задан вместо Comment, если комментарий не влез в payload
+	CommentID int64     `json:"comment_id,omitempty"`
+	Subtree   bool      `json:"subtree,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// PostgresBus рассылает события через LISTEN/NOTIFY мастера, поэтому их
+// получает каждый экземпляр сервиса, подключённый к той же базе.
+type PostgresBus struct {
+	db       *dbpg.DB
+	channel  string
+	load     CommentLoader
+	listener *pq.Listener
+
+	mu       sync.RWMutex
+	handlers []domain.EventHandler
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPostgresBus подписывается на channel отдельным соединением по dsn мастера.
+// load нужен для комментариев, которые не помещаются в уведомление.
+func NewPostgresBus(db *dbpg.DB, dsn, channel string, load CommentLoader) (*PostgresBus, error) {
+	if channel == "" {
+		channel = "comment_events"
+	}
+	b := &PostgresBus{
+		db:      db,
+		channel: channel,
+		load:    load,
+		done:    make(chan struct{}),
+	}
+
+	b.listener = pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			zlog.Logger.Warn().Err(err).Str("channel", channel).Msg("eventbus: listener disconnected")
+		case pq.ListenerEventReconnected:
+			zlog.Logger.Info().Str("channel", channel).Msg("eventbus: listener reconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			zlog.Logger.Warn().Err(err).Str("channel", channel).Msg("eventbus: listener connection attempt failed")
+		}
+	})
+	if err := b.listener.Listen(channel); err != nil {
+		b.listener.Close()
+		return nil, fmt.Errorf("listen %q: %w", channel, err)
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	zlog.Logger.Info().Str("channel", channel).Msg("eventbus: listening")
+	return b, nil
+}
+
+func (b *PostgresBus) Publish(ctx context.Context, e *domain.CommentEvent) error {
+	payload, err := encode(e)
+	if err != nil {
+		return err
+	}
+	if _, err := b.db.Master.ExecContext(ctx, `SELECT pg_notify($1, $2)`, b.channel, payload); err != nil {
+		return fmt.Errorf("notify %q: %w", b.channel, err)
+	}
+	return nil
+}
+
+func (b *PostgresBus) Subscribe(h domain.EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Close отписывается от канала и дожидается обработки текущего события
+func (b *PostgresBus) Close() error {
+	close(b.done)
+	b.wg.Wait()
+	return b.listener.Close()
+}
+
+func (b *PostgresBus) run() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case n, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			if n == nil {
+				// После переподключения уведомления за время обрыва потеряны
+				b.dispatch(&domain.CommentEvent{Type: domain.EventStreamReset, At: time.Now()})
+				continue
+			}
+			e, err := b.decode(n.Extra)
+			if err != nil {
+				zlog.Logger.Error().Err(err).Msg("eventbus: bad notification")
+				continue
+			}
+			b.dispatch(e)
+		case <-ticker.C:
+			if err := b.listener.Ping(); err != nil {
+				zlog.Logger.Warn().Err(err).Msg("eventbus: listener ping failed")
+			}
+		}
+	}
+}
+
+func (b *PostgresBus) dispatch(e *domain.CommentEvent) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	ctx := context.Background()
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
+
+func encode(e *domain.CommentEvent) (string, error) {
+	m := message{ID: e.ID, Seq: e.Seq, Type: e.Type, Comment: e.Comment, Subtree: e.Subtree, At: e.At}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("encode event: %w", err)
+	}
+	if len(data) <= maxPayload || e.Comment == nil {
+		return string(data), nil
+	}
+
+	// Длинный комментарий получатели перечитают из базы сами
+	m.CommentID, m.Comment = e.Comment.ID, nil
+	if data, err = json.Marshal(m); err != nil {
+		return "", fmt.Errorf("encode event: %w", err)
+	}
+	return string(data), nil
+}
+
+func (b *PostgresBus) decode(payload string) (*domain.CommentEvent, error) {
+	var m message
+	if err := json.Unmarshal([]byte(payload), &m); err != nil {
+		return nil, fmt.Errorf("decode event: %w", err)
+	}
+
+	e := &domain.CommentEvent{ID: m.ID, Seq: m.Seq, Type: m.Type, Comment: m.Comment, Subtree: m.Subtree, At: m.At}
+	if e.Comment == nil && m.CommentID != 0 {
+		if b.load == nil {
+			return nil, errors.New("decode event: comment omitted and no loader configured")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		c, err := b.load(ctx, m.CommentID)
+		if err != nil {
+			return nil, fmt.Errorf("load comment id=%d: %w", m.CommentID, err)
+		}
+		if e.Type == domain.EventCommentDeleted {
+			c.Tombstone()
+		}
+		e.Comment = c
+	}
+	return e, nil
+}
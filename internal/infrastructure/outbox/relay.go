@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// cleanupInterval — как часто удалять давно опубликованные события
+const cleanupInterval = time.Hour
+
+// Options настраивает перенос событий из outbox в шину
+type Options struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// Retention — сколько хранить опубликованные события; 0 — не удалять
+	Retention time.Duration
+}
+
+// Sink получает событие в транзакции разбора outbox; ошибка откатывает всю пачку
+type Sink func(ctx context.Context, e *domain.CommentEvent) error
+
+// Relay разбирает таблицу outbox. Надёжные получатели (Sink) записывают событие в той же
+// транзакции, где оно отмечается опубликованным; в шину событие уходит после фиксации
+// и без гарантий — она нужна только живой ленте.
+type Relay struct {
+	repo  domain.OutboxRepository
+	bus   domain.EventPublisher
+	sinks []Sink
+	opts  Options
+}
+
+func NewRelay(repo domain.OutboxRepository, bus domain.EventPublisher, opts Options) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 200 * time.Millisecond
+	}
+	return &Relay{repo: repo, bus: bus, opts: opts}
+}
+
+// AddSink добавляет надёжного получателя событий; вызывать до Run
+func (r *Relay) AddSink(s Sink) {
+	r.sinks = append(r.sinks, s)
+}
+
+// Run публикует события, пока ctx не отменён
+func (r *Relay) Run(ctx context.Context) {
+	zlog.Logger.Info().Msg("outbox: relay started")
+	defer zlog.Logger.Info().Msg("outbox: relay stopped")
+
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+
+	for {
+		events, err := r.repo.Drain(ctx, r.opts.BatchSize, r.deliver)
+		if err != nil && ctx.Err() == nil {
+			zlog.Logger.Error().Err(err).Msg("outbox: drain failed")
+		}
+		r.publish(ctx, events)
+		// Полная пачка — в очереди, скорее всего, есть ещё; не ждём тика
+		if err == nil && len(events) == r.opts.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		if r.opts.Retention > 0 && time.Since(lastCleanup) >= cleanupInterval {
+			lastCleanup = time.Now()
+			if removed, err := r.repo.Cleanup(ctx, lastCleanup.Add(-r.opts.Retention)); err != nil {
+				zlog.Logger.Warn().Err(err).Msg("outbox: cleanup failed")
+			} else if removed > 0 {
+				zlog.Logger.Info().Int64("removed", removed).Msg("outbox: published events cleaned up")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) deliver(ctx context.Context, e *domain.CommentEvent) error {
+	for _, s := range r.sinks {
+		if err := s(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publish отправляет отмеченные события в шину; потерянное уведомление лента
+// переживёт, поэтому ошибки только логируются
+func (r *Relay) publish(ctx context.Context, events []*domain.CommentEvent) {
+	for _, e := range events {
+		if err := r.bus.Publish(ctx, e); err != nil {
+			zlog.Logger.Warn().Err(err).Str("event_id", e.ID).Msg("outbox: publish to event bus failed")
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто MemoryStore выбрасывает давно полные корзины
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// MemoryStore держит корзины в памяти процесса; подходит для одной реплики.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, buckets []Bucket) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	states := make([]*bucket, len(buckets))
+	tokens := make([]float64, len(buckets))
+	limits := make([]Limit, len(buckets))
+	for i, bk := range buckets {
+		b, ok := s.buckets[bk.Key]
+		if !ok {
+			b = &bucket{tokens: float64(bk.Limit.Burst), last: now}
+			s.buckets[bk.Key] = b
+		}
+		states[i], limits[i] = b, bk.Limit
+		tokens[i] = refill(b.tokens, b.last, now, bk.Limit)
+	}
+
+	tokens, results := takeAll(tokens, limits)
+	for i, b := range states {
+		b.tokens, b.last, b.full = tokens[i], now, now.Add(results[i].Reset)
+	}
+	return results, nil
+}
+
+// sweep удаляет корзины, которые уже наполнились: они ничем не отличаются от новых
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
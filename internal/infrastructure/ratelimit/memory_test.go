@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestStore(now *time.Time) *MemoryStore {
+	s := NewMemoryStore()
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{PerMinute: 60, Burst: 2}
+
+	tests := []struct {
+		name    string
+		elapsed []time.Duration // пауза перед каждым запросом
+		allowed []bool
+	}{
+		{"burst then deny", []time.Duration{0, 0, 0}, []bool{true, true, false}},
+		{"refills one token per second", []time.Duration{0, 0, 0, time.Second}, []bool{true, true, false, true}},
+		{"refill is capped by burst", []time.Duration{0, time.Hour, 0, 0}, []bool{true, true, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			s := newTestStore(&now)
+			for i, d := range tt.elapsed {
+				now = now.Add(d)
+				res, err := s.Take(context.Background(), []Bucket{{Key: "k", Limit: limit}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res[0].Allowed != tt.allowed[i] {
+					t.Fatalf("request %d: allowed = %v, want %v", i, res[0].Allowed, tt.allowed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTakeIsAllOrNothing(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newTestStore(&now)
+	ctx := context.Background()
+
+	wide := Bucket{Key: "ip:1", Limit: Limit{PerMinute: 1, Burst: 5}}
+	narrow := Bucket{Key: "thread:t", Limit: Limit{PerMinute: 1, Burst: 1}}
+
+	if res, _ := s.Take(ctx, []Bucket{wide, narrow}); !res[0].Allowed || !res[1].Allowed {
+		t.Fatalf("first request denied: %+v", res)
+	}
+
+	// Узкая корзина пуста: запрос отклонён, и широкая не должна потерять токен
+	for i := 0; i < 3; i++ {
+		res, _ := s.Take(ctx, []Bucket{wide, narrow})
+		if res[1].Allowed {
+			t.Fatalf("narrow bucket allowed on retry %d", i)
+		}
+		if res[0].Remaining != 4 {
+			t.Fatalf("retry %d: wide remaining = %d, want 4", i, res[0].Remaining)
+		}
+		if res[1].RetryAfter <= 0 {
+			t.Fatalf("retry %d: RetryAfter not set", i)
+		}
+	}
+
+	if res, _ := s.Take(ctx, []Bucket{wide}); !res[0].Allowed || res[0].Remaining != 3 {
+		t.Fatalf("wide bucket alone: %+v, want allowed with 3 remaining", res[0])
+	}
+}
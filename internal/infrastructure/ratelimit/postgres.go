@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// PostgresStore хранит корзины в таблице rate_limit_buckets, поэтому
+// лимиты общие для всех реплик приложения.
+type PostgresStore struct {
+	db *dbpg.DB
+	// ttl — сколько хранить корзину после последнего обращения
+	ttl time.Duration
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewPostgresStore(db *dbpg.DB, ttl time.Duration) *PostgresStore {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &PostgresStore{db: db, ttl: ttl}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, buckets []Bucket) ([]Result, error) {
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	var results []Result
+	err := s.db.WithTx(ctx, func(tx *sql.Tx) error {
+		// Корзины блокируются в порядке ключей, чтобы реплики не сцепились в deadlock
+		order := make([]int, len(buckets))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return buckets[order[a]].Key < buckets[order[b]].Key })
+
+		tokens := make([]float64, len(buckets))
+		limits := make([]Limit, len(buckets))
+		var now time.Time
+		for _, i := range order {
+			key, limit := buckets[i].Key, buckets[i].Limit
+			limits[i] = limit
+
+			// Новая корзина создаётся полной; FOR UPDATE сериализует реплики на одном ключе
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+				VALUES ($1, $2, now())
+				ON CONFLICT (key) DO NOTHING
+			`, key, limit.Burst); err != nil {
+				return fmt.Errorf("insert bucket %s: %w", key, err)
+			}
+
+			var last time.Time
+			if err := tx.QueryRowContext(ctx, `
+				SELECT tokens, updated_at, now()
+				FROM rate_limit_buckets
+				WHERE key = $1
+				FOR UPDATE
+			`, key).Scan(&tokens[i], &last, &now); err != nil {
+				return fmt.Errorf("select bucket %s: %w", key, err)
+			}
+			tokens[i] = refill(tokens[i], last, now, limit)
+		}
+
+		tokens, results = takeAll(tokens, limits)
+
+		for i, b := range buckets {
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3 WHERE key = $1
+			`, b.Key, tokens[i], now); err != nil {
+				return fmt.Errorf("update bucket %s: %w", b.Key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("take tokens: %w", err)
+	}
+
+	s.cleanup()
+	return results, nil
+}
+
+// cleanup не чаще раза в ttl удаляет корзины, к которым давно не обращались
+func (s *PostgresStore) cleanup() {
+	s.mu.Lock()
+	if time.Since(s.lastCleanup) < s.ttl {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = time.Now()
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := s.db.Master.ExecContext(ctx, `
+			DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1)
+		`, s.ttl.Seconds())
+		if err != nil {
+			zlog.Logger.Warn().Err(err).Msg("ratelimit: cleanup failed")
+			return
+		}
+		n, _ := res.RowsAffected()
+		zlog.Logger.Debug().Int64("deleted", n).Msg("ratelimit: stale buckets removed")
+	}()
+}
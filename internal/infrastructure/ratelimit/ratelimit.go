@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit — параметры token bucket: корзина вмещает Burst токенов
+// и пополняется на PerMinute токенов в минуту.
+type Limit struct {
+	PerMinute float64
+	Burst     int
+}
+
+func (l Limit) Enabled() bool {
+	return l.PerMinute > 0 && l.Burst > 0
+}
+
+func (l Limit) perSecond() float64 {
+	return l.PerMinute / 60
+}
+
+// Result — итог попытки взять токен из корзины
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter — через сколько появится следующий токен (0, если запрос разрешён)
+	RetryAfter time.Duration
+	// Reset — через сколько корзина снова наполнится целиком
+	Reset time.Duration
+}
+
+// Bucket — корзина, из которой запрос берёт токен
+type Bucket struct {
+	Key   string
+	Limit Limit
+}
+
+// Store хранит состояние корзин. Take атомарно берёт по токену из каждой корзины,
+// только если токен есть во всех; иначе не списывает ни из одной, чтобы отказ
+// одной корзины не тратил лимиты остальных. Результаты идут в порядке buckets.
+type Store interface {
+	Take(ctx context.Context, buckets []Bucket) ([]Result, error)
+}
+
+// refill пополняет корзину токенами, накопленными с last до now
+func refill(tokens float64, last, now time.Time, limit Limit) float64 {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.perSecond())
+	}
+	return tokens
+}
+
+// takeAll списывает по токену из всех пополненных корзин, если в каждой он есть.
+// Возвращает новые числа токенов и результаты; Allowed у корзины — был ли в ней токен.
+func takeAll(tokens []float64, limits []Limit) ([]float64, []Result) {
+	all := true
+	for _, t := range tokens {
+		all = all && t >= 1
+	}
+
+	results := make([]Result, len(tokens))
+	for i, t := range tokens {
+		rate, burst := limits[i].perSecond(), float64(limits[i].Burst)
+		res := Result{Limit: limits[i].Burst, Allowed: t >= 1}
+		if all {
+			t--
+			tokens[i] = t
+		}
+		if !res.Allowed {
+			res.RetryAfter = seconds((1 - t) / rate)
+		}
+		res.Remaining = int(math.Floor(t))
+		res.Reset = seconds((burst - t) / rate)
+		results[i] = res
+	}
+	return tokens, results
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
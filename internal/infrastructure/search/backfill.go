@@ -0,0 +1,58 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// Backfill индексирует для поиска комментарии, записанные до появления индекса.
+// Работает пачками вне миграций и завершается, когда индексировать больше нечего.
+type Backfill struct {
+	repo      domain.SearchIndexRepository
+	batchSize int
+	pause     time.Duration
+}
+
+// NewBackfill создаёт задачу; pause — передышка для базы между пачками
+func NewBackfill(repo domain.SearchIndexRepository, batchSize int, pause time.Duration) *Backfill {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &Backfill{repo: repo, batchSize: batchSize, pause: pause}
+}
+
+// Run индексирует пачки, пока они не кончатся или ctx не будет отменён
+func (b *Backfill) Run(ctx context.Context) {
+	var lastID int64
+	total := 0
+	for {
+		wait := b.pause
+		next, n, err := b.repo.IndexMissing(ctx, lastID, b.batchSize)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			// Пачка откатилась; повторим её позже с того же места
+			zlog.Logger.Warn().Err(err).Int64("after_id", lastID).Msg("search: backfill batch failed")
+			wait = max(wait, time.Second)
+		case n == 0:
+			if total > 0 {
+				zlog.Logger.Info().Int("indexed", total).Msg("search: backfill completed")
+			}
+			return
+		default:
+			lastID = next
+			total += n
+			zlog.Logger.Debug().Int("indexed", total).Int64("last_id", lastID).Msg("search: backfill batch indexed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
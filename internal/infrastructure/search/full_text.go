@@ -8,27 +8,52 @@ import (
 	"github.com/yokitheyo/CommentTree/internal/domain"
 )
 
+const (
+	defaultHighlightStart = "<mark>"
+	defaultHighlightStop  = "</mark>"
+)
+
 type FullTextSearcher interface {
-	SearchComments(ctx context.Context, query string, limit, offset int) ([]*domain.Comment, error)
+	SearchComments(ctx context.Context, query domain.SearchQuery) ([]*domain.SearchHit, error)
 }
 
 type PostgresFullText struct {
-	repo domain.CommentRepository
+	repo           domain.CommentRepository
+	highlightStart string
+	highlightStop  string
 }
 
-func NewPostgresFullText(repo domain.CommentRepository) *PostgresFullText {
-	return &PostgresFullText{repo: repo}
+// NewPostgresFullText создаёт поисковик; пустые маркеры заменяются на <mark>…</mark>.
+func NewPostgresFullText(repo domain.CommentRepository, highlightStart, highlightStop string) *PostgresFullText {
+	if highlightStart == "" {
+		highlightStart = defaultHighlightStart
+	}
+	if highlightStop == "" {
+		highlightStop = defaultHighlightStop
+	}
+	return &PostgresFullText{
+		repo:           repo,
+		highlightStart: highlightStart,
+		highlightStop:  highlightStop,
+	}
 }
 
-func (f *PostgresFullText) SearchComments(ctx context.Context, query string, limit, offset int) ([]*domain.Comment, error) {
-	zlog.Logger.Debug().Str("query", query).Int("limit", limit).Int("offset", offset).Msg("search: SearchComments starting")
+func (f *PostgresFullText) SearchComments(ctx context.Context, query domain.SearchQuery) ([]*domain.SearchHit, error) {
+	zlog.Logger.Debug().Str("query", query.Text).Int("limit", query.Page.Limit).Int("offset", query.Page.Offset).Str("mode", string(query.Mode)).Msg("search: SearchComments starting")
+
+	if query.HighlightStart == "" {
+		query.HighlightStart = f.highlightStart
+	}
+	if query.HighlightStop == "" {
+		query.HighlightStop = f.highlightStop
+	}
 
-	comments, err := f.repo.Search(ctx, query, limit, offset)
+	hits, err := f.repo.Search(ctx, query)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Str("query", query).Msg("search: SearchComments failed")
-		return nil, fmt.Errorf("search comments %q: %w", query, err)
+		zlog.Logger.Error().Err(err).Str("query", query.Text).Msg("search: SearchComments failed")
+		return nil, fmt.Errorf("search comments %q: %w", query.Text, err)
 	}
 
-	zlog.Logger.Info().Str("query", query).Int("results", len(comments)).Msg("search: SearchComments completed")
-	return comments, nil
+	zlog.Logger.Info().Str("query", query.Text).Int("results", len(hits)).Msg("search: SearchComments completed")
+	return hits, nil
 }
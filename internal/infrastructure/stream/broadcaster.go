@@ -0,0 +1,150 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// subscriberBuffer — сколько новых событий ждёт подписчика, прежде чем его отключат
+const subscriberBuffer = 64
+
+// Broadcaster — брокер событий внутри процесса. Последние события хранятся в
+// кольцевом буфере, чтобы переподключившийся клиент получил пропущенное.
+//
+// Номер события (Seq) — id строки outbox, общий для всех экземпляров, поэтому
+// Last-Event-ID, выданный одним экземпляром, понятен другому. Номера растут не
+// строго в порядке доставки (транзакция с меньшим id может зафиксироваться позже),
+// поэтому буфер хранит события в порядке прихода, а продолжение ищется по точному номеру.
+type Broadcaster struct {
+	mu   sync.Mutex
+	ring []*domain.CommentEvent
+	// next — позиция для следующей записи в ring
+	next int
+	full bool
+	subs map[chan *domain.CommentEvent]struct{}
+	// closed — сервер останавливается, новых подписчиков не принимаем
+	closed bool
+}
+
+func NewBroadcaster(bufferSize int) *Broadcaster {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	return &Broadcaster{
+		ring: make([]*domain.CommentEvent, bufferSize),
+		subs: make(map[chan *domain.CommentEvent]struct{}),
+	}
+}
+
+// Publish сохраняет событие и рассылает его подписчикам. Тех, кто не успевает
+// читать, отключает: они вернутся с Last-Event-ID и дочитают из буфера.
+// Событие без номера (reset из шины после потери уведомлений) в буфер не попадает
+// и очищает его: продолжить ленту через пропуск уже нельзя.
+// Подходит как domain.EventHandler для шины событий.
+func (b *Broadcaster) Publish(_ context.Context, event *domain.CommentEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Событие из шины могут читать и другие обработчики — дополняем копию
+	e := *event
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	if e.Seq > 0 {
+		b.ring[b.next] = &e
+		b.next = (b.next + 1) % len(b.ring)
+		if b.next == 0 {
+			b.full = true
+		}
+	} else {
+		clear(b.ring)
+		b.next, b.full = 0, false
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- &e:
+		default:
+			zlog.Logger.Warn().Int64("seq", e.Seq).Msg("stream: slow subscriber dropped")
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *Broadcaster) Subscribe(ctx context.Context, after int64) <-chan *domain.CommentEvent {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		ch := make(chan *domain.CommentEvent)
+		close(ch)
+		return ch
+	}
+	backlog, complete := b.since(after)
+	ch := make(chan *domain.CommentEvent, len(backlog)+subscriberBuffer)
+	if !complete {
+		ch <- &domain.CommentEvent{Seq: after, Type: domain.EventStreamReset, At: time.Now()}
+	}
+	for _, e := range backlog {
+		ch <- e
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}()
+
+	return ch
+}
+
+// Close закрывает каналы всех подписчиков, чтобы SSE-запросы завершились и
+// http.Server.Shutdown не ждал их до таймаута. Повторный вызов ничего не делает.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// since возвращает сохранённые события, пришедшие после события с номером after;
+// complete ложно, если такого события в буфере нет (вытеснено или ещё не пришло).
+// Вызывается под mu.
+func (b *Broadcaster) since(after int64) (events []*domain.CommentEvent, complete bool) {
+	if after <= 0 {
+		return nil, true
+	}
+
+	stored := b.next
+	if b.full {
+		stored = len(b.ring)
+	}
+	// i-е по старшинству событие лежит в ring[(oldest+i) % len]
+	oldest := (b.next - stored + len(b.ring)) % len(b.ring)
+	at := func(i int) *domain.CommentEvent { return b.ring[(oldest+i)%len(b.ring)] }
+
+	for i := stored - 1; i >= 0; i-- {
+		if at(i).Seq != after {
+			continue
+		}
+		for j := i + 1; j < stored; j++ {
+			events = append(events, at(j))
+		}
+		return events, true
+	}
+	return nil, false
+}
@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// publish публикует события с номерами seqs в указанном порядке
+func publish(b *Broadcaster, seqs ...int64) {
+	for _, seq := range seqs {
+		b.Publish(context.Background(), &domain.CommentEvent{Seq: seq, Type: domain.EventCommentCreated})
+	}
+}
+
+// span возвращает номера from..to
+func span(from, to int64) []int64 {
+	var out []int64
+	for seq := from; seq <= to; seq++ {
+		out = append(out, seq)
+	}
+	return out
+}
+
+func seqs(events []*domain.CommentEvent) []int64 {
+	out := make([]int64, len(events))
+	for i, e := range events {
+		out[i] = e.Seq
+	}
+	return out
+}
+
+func TestBroadcasterSince(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		published    []int64
+		after        int64
+		want         []int64
+		wantComplete bool
+	}{
+		{"new client", 4, span(1, 2), 0, nil, true},
+		{"partly filled, from start", 4, span(11, 13), 11, []int64{12, 13}, true},
+		{"partly filled, middle", 4, span(11, 13), 12, []int64{13}, true},
+		{"exactly full", 4, span(11, 14), 11, []int64{12, 13, 14}, true},
+		{"wrapped, oldest kept", 4, span(11, 16), 13, []int64{14, 15, 16}, true},
+		{"wrapped, evicted", 4, span(11, 16), 12, nil, false},
+		{"wrapped many times", 4, span(1, 23), 21, []int64{22, 23}, true},
+		{"up to date", 4, span(11, 16), 16, nil, true},
+		{"gaps in numbering", 4, []int64{5, 9, 20}, 9, []int64{20}, true},
+		{"committed out of order", 4, []int64{10, 12, 11, 13}, 12, []int64{11, 13}, true},
+		{"not received yet", 4, span(11, 16), 17, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroadcaster(tt.size)
+			publish(b, tt.published...)
+
+			events, complete := b.since(tt.after)
+			if complete != tt.wantComplete {
+				t.Fatalf("complete = %v, want %v", complete, tt.wantComplete)
+			}
+			if got := seqs(events); !slices.Equal(got, tt.want) {
+				t.Fatalf("since(%d) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBroadcasterUnnumberedResetClearsBuffer(t *testing.T) {
+	b := NewBroadcaster(4)
+	publish(b, 1, 2)
+	b.Publish(context.Background(), &domain.CommentEvent{Type: domain.EventStreamReset})
+	publish(b, 7)
+
+	// Между 2 и 7 уведомления потеряны — продолжить с 2 нельзя, с 7 можно
+	if events, complete := b.since(2); complete || len(events) != 0 {
+		t.Fatalf("since(2) = %v, %v; want reset", seqs(events), complete)
+	}
+	if events, complete := b.since(7); !complete || len(events) != 0 {
+		t.Fatalf("since(7) = %v, %v; want up to date", seqs(events), complete)
+	}
+}
+
+func TestBroadcasterSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name  string
+		after int64
+		want  []domain.CommentEventType
+		seqs  []int64
+	}{
+		{"resume", 12, []domain.CommentEventType{domain.EventCommentCreated, domain.EventCommentCreated}, []int64{13, 14}},
+		// Событие 10 вытеснено: reset, затем только новые
+		{"evicted", 10, []domain.CommentEventType{domain.EventStreamReset, domain.EventCommentCreated}, []int64{10, 14}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroadcaster(2)
+			publish(b, 11, 12, 13)
+			ch := b.Subscribe(ctx, tt.after)
+			publish(b, 14)
+
+			for i, typ := range tt.want {
+				select {
+				case e := <-ch:
+					if e.Type != typ || e.Seq != tt.seqs[i] {
+						t.Fatalf("got %s #%d, want %s #%d", e.Type, e.Seq, typ, tt.seqs[i])
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("timed out waiting for %s #%d", typ, tt.seqs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBroadcasterSubscribeCancel(t *testing.T) {
+	b := NewBroadcaster(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := b.Subscribe(ctx, 0)
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("unexpected event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestBroadcasterClose(t *testing.T) {
+	b := NewBroadcaster(4)
+	open := b.Subscribe(context.Background(), 0)
+	b.Close()
+
+	if _, ok := <-open; ok {
+		t.Fatal("subscription not closed by Close")
+	}
+	if _, ok := <-b.Subscribe(context.Background(), 0); ok {
+		t.Fatal("Subscribe after Close returned an open channel")
+	}
+	publish(b, 1)
+}
@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/retry"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+const (
+	EventHeader     = "X-CommentTree-Event"
+	DeliveryHeader  = "X-CommentTree-Delivery"
+	TimestampHeader = "X-CommentTree-Timestamp"
+	SignatureHeader = "X-CommentTree-Signature"
+
+	// maxErrorBody — сколько байт ответа получателя сохранять в журнал
+	maxErrorBody = 512
+)
+
+// Options настраивает доставку вебхуков
+type Options struct {
+	// Backoff: Attempts — сколько попыток до dead-letter, Delay и Backoff — пауза
+	// перед второй попыткой и её множитель для следующих
+	Backoff retry.Strategy
+	// BatchSize — сколько доставок забирать за один проход
+	BatchSize    int
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+// Dispatcher — фоновый воркер, доставляющий события из очереди webhook_deliveries.
+// Несколько экземпляров могут работать одновременно: доставки разбираются через SKIP LOCKED.
+type Dispatcher struct {
+	repo   domain.WebhookRepository
+	client *http.Client
+	opts   Options
+}
+
+func NewDispatcher(repo domain.WebhookRepository, opts Options) *Dispatcher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Backoff.Attempts <= 0 {
+		opts.Backoff.Attempts = 1
+	}
+	return &Dispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+	}
+}
+
+// Run разбирает очередь, пока ctx не отменён
+func (d *Dispatcher) Run(ctx context.Context) {
+	zlog.Logger.Info().Msg("webhooks: dispatcher started")
+	defer zlog.Logger.Info().Msg("webhooks: dispatcher stopped")
+
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Полная пачка — скорее всего, очередь не пуста, берём следующую сразу
+		if d.runOnce(ctx) == d.opts.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) runOnce(ctx context.Context) int {
+	// Пока доставка в работе, другие экземпляры её не трогают; запас — на запись результата
+	lease := d.opts.Timeout + 30*time.Second
+
+	deliveries, err := d.repo.ClaimDue(ctx, d.opts.BatchSize, lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Logger.Error().Err(err).Msg("webhooks: claim deliveries failed")
+		}
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *domain.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+	return len(deliveries)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *domain.WebhookDelivery) {
+	started := time.Now()
+	code, sendErr := d.send(ctx, delivery, started)
+
+	attempt := &domain.DeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+		Duration:   time.Since(started),
+	}
+	if code != 0 {
+		attempt.StatusCode = &code
+	}
+	if sendErr != nil {
+		msg := sendErr.Error()
+		attempt.Error = &msg
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode, delivery.LastError = attempt.StatusCode, attempt.Error
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		delivery.Status, delivery.DeliveredAt = domain.DeliveryDelivered, &now
+	case delivery.Attempts-delivery.AttemptsBeforeReplay >= d.opts.Backoff.Attempts:
+		delivery.Status = domain.DeliveryDead
+		zlog.Logger.Warn().Err(sendErr).Int64("delivery_id", delivery.ID).Int("attempts", delivery.Attempts).
+			Msg("webhooks: delivery moved to dead letter")
+	default:
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts - delivery.AttemptsBeforeReplay))
+	}
+
+	// Результат пишем даже при остановке сервиса, иначе попытка повторится без записи в журнале
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := d.repo.RecordAttempt(recordCtx, delivery, attempt); err != nil {
+		zlog.Logger.Error().Err(err).Int64("delivery_id", delivery.ID).Msg("webhooks: record attempt failed")
+		return
+	}
+
+	zlog.Logger.Debug().Int64("delivery_id", delivery.ID).Str("status", string(delivery.Status)).
+		Int("attempt", attempt.Attempt).Msg("webhooks: delivery attempted")
+}
+
+// send отправляет событие; ошибка — всё, кроме ответа 2xx
+func (d *Dispatcher) send(ctx context.Context, delivery *domain.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CommentTree-Webhook/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.EventID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff — пауза после attempts неудачных попыток: Delay * Backoff^(attempts-1)
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	factor := d.opts.Backoff.Backoff
+	if factor < 1 {
+		factor = 1
+	}
+	return time.Duration(float64(d.opts.Backoff.Delay) * math.Pow(factor, float64(attempts-1)))
+}
+
+// Sign вычисляет подпись тела: hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Метка времени входит в подпись, чтобы перехваченный запрос нельзя было повторить позже.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
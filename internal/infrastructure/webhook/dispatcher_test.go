@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/wb-go/wbf/retry"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+func TestSign(t *testing.T) {
+	// Значения посчитаны независимо: hmac.new(secret, timestamp + b"." + body, sha256).hexdigest()
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{"payload", "secret", "1700000000", `{"id":1}`, "3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11"},
+		{"empty body", "key", "1700000000", "", "0f1cc1f811f42fd12af9618acf321769899fa521fe07a642f70a61785e130770"},
+		{"empty secret", "", "0", "", "b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Fatalf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Каждая часть входит в подпись
+	base := Sign("secret", "1700000000", []byte(`{"id":1}`))
+	for name, got := range map[string]string{
+		"secret":    Sign("secret2", "1700000000", []byte(`{"id":1}`)),
+		"timestamp": Sign("secret", "1700000001", []byte(`{"id":1}`)),
+		"body":      Sign("secret", "1700000000", []byte(`{"id":2}`)),
+		// разделитель не даёт перенести цифры из метки в тело
+		"boundary": Sign("secret", "170000000", []byte(`0.{"id":1}`)),
+	} {
+		if got == base {
+			t.Errorf("changing the %s keeps the signature", name)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		delay  time.Duration
+		factor float64
+		want   []time.Duration // паузы после 1, 2, 3... неудачных попыток
+	}{
+		{"exponential", 10 * time.Second, 3, []time.Duration{10 * time.Second, 30 * time.Second, 90 * time.Second, 270 * time.Second}},
+		{"doubling", time.Second, 2, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{"fractional factor", 4 * time.Second, 1.5, []time.Duration{4 * time.Second, 6 * time.Second, 9 * time.Second}},
+		{"factor one is constant", 5 * time.Second, 1, []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{"factor below one does not shrink", 5 * time.Second, 0.5, []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{"zero factor", 5 * time.Second, 0, []time.Duration{5 * time.Second, 5 * time.Second}},
+		{"negative factor", 5 * time.Second, -2, []time.Duration{5 * time.Second, 5 * time.Second}},
+		{"zero delay", 0, 3, []time.Duration{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(nil, Options{Backoff: retry.Strategy{Attempts: 8, Delay: tt.delay, Backoff: tt.factor}})
+			for i, want := range tt.want {
+				if got := d.backoff(i + 1); got != want {
+					t.Fatalf("backoff(%d) = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// fakeRepo запоминает результат последней попытки
+type fakeRepo struct {
+	domain.WebhookRepository
+	delivery *domain.WebhookDelivery
+	attempt  *domain.DeliveryAttempt
+}
+
+func (r *fakeRepo) RecordAttempt(_ context.Context, d *domain.WebhookDelivery, a *domain.DeliveryAttempt) error {
+	r.delivery, r.attempt = d, a
+	return nil
+}
+
+func TestDeliver(t *testing.T) {
+	const maxAttempts = 3
+
+	tests := []struct {
+		name          string
+		status        int
+		attempts      int
+		beforeReplay  int
+		wantStatus    domain.DeliveryStatus
+		wantAttempt   int
+		wantNextAfter time.Duration // 0 — повтор не планируется
+	}{
+		{"first try succeeds", http.StatusNoContent, 0, 0, domain.DeliveryDelivered, 1, 0},
+		{"first failure is retried", http.StatusInternalServerError, 0, 0, domain.DeliveryPending, 1, time.Minute},
+		{"second failure waits longer", http.StatusBadGateway, 1, 0, domain.DeliveryPending, 2, 2 * time.Minute},
+		{"last failure goes to dead letter", http.StatusInternalServerError, 2, 0, domain.DeliveryDead, 3, 0},
+		{"redirect is a failure", http.StatusFound, 2, 0, domain.DeliveryDead, 3, 0},
+		// после переигровки номера продолжают расти, а лимит отсчитывается заново
+		{"replay keeps numbering", http.StatusInternalServerError, 3, 3, domain.DeliveryPending, 4, time.Minute},
+		{"replay gets its own budget", http.StatusInternalServerError, 5, 3, domain.DeliveryDead, 6, 0},
+		{"replay succeeds", http.StatusOK, 3, 3, domain.DeliveryDelivered, 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			var gotBody []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			repo := &fakeRepo{}
+			d := NewDispatcher(repo, Options{Backoff: retry.Strategy{Attempts: maxAttempts, Delay: time.Minute, Backoff: 2}})
+			delivery := &domain.WebhookDelivery{
+				ID:                   7,
+				EventID:              "evt-1",
+				EventType:            "comment.created",
+				Payload:              []byte(`{"id":"evt-1"}`),
+				Status:               domain.DeliveryPending,
+				Attempts:             tt.attempts,
+				AttemptsBeforeReplay: tt.beforeReplay,
+				URL:                  srv.URL,
+				Secret:               "s3cret",
+			}
+			before := time.Now()
+			d.deliver(context.Background(), delivery)
+
+			if repo.attempt == nil {
+				t.Fatal("attempt not recorded")
+			}
+			if repo.attempt.Attempt != tt.wantAttempt || repo.delivery.Attempts != tt.wantAttempt {
+				t.Fatalf("attempt = %d, delivery attempts = %d, want %d", repo.attempt.Attempt, repo.delivery.Attempts, tt.wantAttempt)
+			}
+			if repo.delivery.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", repo.delivery.Status, tt.wantStatus)
+			}
+			if repo.attempt.StatusCode == nil || *repo.attempt.StatusCode != tt.status {
+				t.Fatalf("recorded status code = %v, want %d", repo.attempt.StatusCode, tt.status)
+			}
+			if (repo.attempt.Error == nil) != (tt.wantStatus == domain.DeliveryDelivered) {
+				t.Fatalf("recorded error = %v for status %s", repo.attempt.Error, tt.wantStatus)
+			}
+			if tt.wantNextAfter > 0 {
+				wait := repo.delivery.NextAttemptAt.Sub(before)
+				if wait < tt.wantNextAfter || wait > tt.wantNextAfter+5*time.Second {
+					t.Fatalf("next attempt in %v, want %v", wait, tt.wantNextAfter)
+				}
+			}
+
+			ts := got.Get(TimestampHeader)
+			if _, err := strconv.ParseInt(ts, 10, 64); err != nil {
+				t.Fatalf("timestamp header %q: %v", ts, err)
+			}
+			if want := "sha256=" + Sign("s3cret", ts, gotBody); got.Get(SignatureHeader) != want {
+				t.Fatalf("signature = %q, want %q", got.Get(SignatureHeader), want)
+			}
+			if got.Get(DeliveryHeader) != "evt-1" || got.Get(EventHeader) != "comment.created" {
+				t.Fatalf("headers = %v", got)
+			}
+		})
+	}
+}
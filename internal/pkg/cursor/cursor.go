@@ -0,0 +1,50 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type payload struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"id"`
+	Score     *float64  `json:"s,omitempty"`
+}
+
+// Encode превращает курсор в непрозрачную строку для клиента
+func Encode(c *domain.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	raw, _ := json.Marshal(payload{CreatedAt: c.CreatedAt, ID: c.ID, Score: c.Score})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode разбирает строку, полученную от Encode; пустая строка даёт nil
+func Decode(s string) (*domain.Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var p payload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if p.ID <= 0 || p.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &domain.Cursor{CreatedAt: p.CreatedAt, ID: p.ID, Score: p.Score}, nil
+}
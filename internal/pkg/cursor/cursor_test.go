@@ -0,0 +1,81 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	score := -1.5
+	at := time.Date(2026, 1, 28, 12, 30, 0, 123456000, time.UTC)
+
+	tests := []struct {
+		name string
+		in   *domain.Cursor
+	}{
+		{"time and id", &domain.Cursor{CreatedAt: at, ID: 42}},
+		{"with score", &domain.Cursor{CreatedAt: at, ID: 7, Score: &score}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(Encode(tt.in))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !got.CreatedAt.Equal(tt.in.CreatedAt) || got.ID != tt.in.ID {
+				t.Fatalf("Decode() = %+v, want %+v", got, tt.in)
+			}
+			if (got.Score == nil) != (tt.in.Score == nil) || (got.Score != nil && *got.Score != *tt.in.Score) {
+				t.Fatalf("Score = %v, want %v", got.Score, tt.in.Score)
+			}
+		})
+	}
+}
+
+func TestEncodeNil(t *testing.T) {
+	if got := Encode(nil); got != "" {
+		t.Fatalf("Encode(nil) = %q, want empty", got)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name    string
+		in      string
+		wantNil bool
+		wantErr bool
+	}{
+		{"empty", "", true, false},
+		{"not base64", "!!!", false, true},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"t":"2026-01-28T12:30:00Z","id":1}`)), false, true},
+		{"not json", enc("hello"), false, true},
+		{"zero id", enc(`{"t":"2026-01-28T12:30:00Z","id":0}`), false, true},
+		{"negative id", enc(`{"t":"2026-01-28T12:30:00Z","id":-5}`), false, true},
+		{"missing time", enc(`{"id":1}`), false, true},
+		{"bad time", enc(`{"t":"yesterday","id":1}`), false, true},
+		{"valid", enc(`{"t":"2026-01-28T12:30:00Z","id":1}`), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("Decode(%q) error = %v, want ErrInvalidCursor", tt.in, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode(%q) error = %v", tt.in, err)
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("Decode(%q) = %+v, want nil = %v", tt.in, got, tt.wantNil)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// maxEdits ограничивает число правок, которое ищет алгоритм Майерса на одном участке;
+// если их больше, участок считается заменённым целиком. Память линейна по длине текста,
+// время — O((N+M)·maxEdits) на уровень рекурсии.
+const maxEdits = 1000
+
+// Words строит пословную разницу между a и b. Пробелы сохраняются как отдельные токены,
+// поэтому склейка Text всех equal+delete фрагментов даёт a, а equal+insert — b.
+func Words(a, b string) []domain.DiffChunk {
+	d := &differ{at: tokenize(a), bt: tokenize(b)}
+	// Сравнение строк заменяется сравнением чисел
+	ids := make(map[string]int)
+	intern := func(tokens []string) []int {
+		out := make([]int, len(tokens))
+		for i, t := range tokens {
+			id, ok := ids[t]
+			if !ok {
+				id = len(ids)
+				ids[t] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	d.a, d.b = intern(d.at), intern(d.bt)
+
+	d.compare(0, len(d.a), 0, len(d.b))
+	return d.chunks
+}
+
+type differ struct {
+	at, bt []string
+	a, b   []int
+	chunks []domain.DiffChunk
+}
+
+func (d *differ) add(op, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(d.chunks); n > 0 && d.chunks[n-1].Op == op {
+		d.chunks[n-1].Text += text
+		return
+	}
+	d.chunks = append(d.chunks, domain.DiffChunk{Op: op, Text: text})
+}
+
+// compare сравнивает a[a0:a1] и b[b0:b1]
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.add(domain.DiffEqual, d.at[a0])
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && d.a[a1-1-suffix] == d.b[b1-1-suffix] {
+		suffix++
+	}
+	ae, be := a1-suffix, b1-suffix
+
+	if a0 < ae && b0 < be {
+		if x, y, ok := d.bisect(a0, ae, b0, be); ok {
+			d.compare(a0, x, b0, y)
+			d.compare(x, ae, y, be)
+		} else {
+			d.add(domain.DiffDelete, strings.Join(d.at[a0:ae], ""))
+			d.add(domain.DiffInsert, strings.Join(d.bt[b0:be], ""))
+		}
+	} else {
+		d.add(domain.DiffDelete, strings.Join(d.at[a0:ae], ""))
+		d.add(domain.DiffInsert, strings.Join(d.bt[b0:be], ""))
+	}
+
+	d.add(domain.DiffEqual, strings.Join(d.at[ae:a1], ""))
+}
+
+// bisect ищет середину кратчайшего пути правок (Myers, 1986) встречным поиском с обоих
+// концов и возвращает точку, которая делит задачу на две меньшие. Участки начинаются и
+// заканчиваются различающимися токенами. ok = false, если правок больше maxEdits.
+func (d *differ) bisect(a0, a1, b0, b1 int) (x, y int, ok bool) {
+	a, b := d.a[a0:a1], d.b[b0:b1]
+	n, m := len(a), len(b)
+	limit := min((n+m+1)/2, maxEdits)
+
+	off := limit
+	vf := make([]int, 2*limit+2)
+	vb := make([]int, 2*limit+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[off+1], vb[off+1] = 0, 0
+
+	delta := n - m
+	// При нечётной разнице пути встречаются на прямом проходе, при чётной — на обратном
+	front := delta%2 != 0
+	// Диагонали, ушедшие за край таблицы, дальше не просматриваются
+	var fStart, fEnd, bStart, bEnd int
+
+	for e := 0; e < limit; e++ {
+		for k := -e + fStart; k <= e-fEnd; k += 2 {
+			var x1 int
+			if k == -e || (k != e && vf[off+k-1] < vf[off+k+1]) {
+				x1 = vf[off+k+1]
+			} else {
+				x1 = vf[off+k-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			vf[off+k] = x1
+
+			switch {
+			case x1 > n:
+				fEnd += 2
+			case y1 > m:
+				fStart += 2
+			case front:
+				if kb := off + delta - k; kb >= 0 && kb < len(vb) && vb[kb] != -1 && x1 >= n-vb[kb] {
+					return d.split(a0, b0, n, m, x1, y1)
+				}
+			}
+		}
+
+		for k := -e + bStart; k <= e-bEnd; k += 2 {
+			var x2 int
+			if k == -e || (k != e && vb[off+k-1] < vb[off+k+1]) {
+				x2 = vb[off+k+1]
+			} else {
+				x2 = vb[off+k-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && a[n-1-x2] == b[m-1-y2] {
+				x2++
+				y2++
+			}
+			vb[off+k] = x2
+
+			switch {
+			case x2 > n:
+				bEnd += 2
+			case y2 > m:
+				bStart += 2
+			case !front:
+				if kf := off + delta - k; kf >= 0 && kf < len(vf) && vf[kf] != -1 {
+					x1 := vf[kf]
+					if y1 := off + x1 - kf; x1 >= n-x2 {
+						return d.split(a0, b0, n, m, x1, y1)
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// split переводит точку встречи в координаты всего текста; точка в углу участка
+// задачу не уменьшает, и тогда участок считается заменённым целиком
+func (d *differ) split(a0, b0, n, m, x, y int) (int, int, bool) {
+	if (x == 0 && y == 0) || (x == n && y == m) {
+		return 0, 0, false
+	}
+	return a0 + x, b0 + y, true
+}
+
+// tokenize делит текст на чередующиеся слова и пробельные промежутки
+func tokenize(s string) []string {
+	var tokens []string
+	start := 0
+	var prevSpace bool
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > start && space != prevSpace {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		prevSpace = space
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// sides собирает исходный и новый текст из фрагментов разницы
+func sides(chunks []domain.DiffChunk) (a, b string) {
+	var sa, sb strings.Builder
+	for _, c := range chunks {
+		switch c.Op {
+		case domain.DiffEqual:
+			sa.WriteString(c.Text)
+			sb.WriteString(c.Text)
+		case domain.DiffDelete:
+			sa.WriteString(c.Text)
+		case domain.DiffInsert:
+			sb.WriteString(c.Text)
+		}
+	}
+	return sa.String(), sb.String()
+}
+
+// equalTokens считает токены, оставшиеся без изменений
+func equalTokens(chunks []domain.DiffChunk) int {
+	n := 0
+	for _, c := range chunks {
+		if c.Op == domain.DiffEqual {
+			n += len(tokenize(c.Text))
+		}
+	}
+	return n
+}
+
+// lcs — длина наибольшей общей подпоследовательности токенов, эталон для проверки
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		cur := make([]int, len(b)+1)
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev = cur
+	}
+	return prev[0]
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []domain.DiffChunk
+	}{
+		{"identical", "same text", "same text", []domain.DiffChunk{{Op: domain.DiffEqual, Text: "same text"}}},
+		{"both empty", "", "", nil},
+		{"from empty", "", "new", []domain.DiffChunk{{Op: domain.DiffInsert, Text: "new"}}},
+		{"to empty", "old", "", []domain.DiffChunk{{Op: domain.DiffDelete, Text: "old"}}},
+		{"word replaced", "the quick fox", "the slow fox", []domain.DiffChunk{
+			{Op: domain.DiffEqual, Text: "the "},
+			{Op: domain.DiffDelete, Text: "quick"},
+			{Op: domain.DiffInsert, Text: "slow"},
+			{Op: domain.DiffEqual, Text: " fox"},
+		}},
+		{"word inserted", "a c", "a b c", []domain.DiffChunk{
+			{Op: domain.DiffEqual, Text: "a "},
+			{Op: domain.DiffInsert, Text: "b "},
+			{Op: domain.DiffEqual, Text: "c"},
+		}},
+		{"whitespace change", "a b", "a  b", []domain.DiffChunk{
+			{Op: domain.DiffEqual, Text: "a"},
+			{Op: domain.DiffDelete, Text: " "},
+			{Op: domain.DiffInsert, Text: "  "},
+			{Op: domain.DiffEqual, Text: "b"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Words(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Words(%q, %q) = %+v, want %+v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordsIsMinimal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d"}
+	text := func() string {
+		n := rnd.Intn(30)
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = words[rnd.Intn(len(words))]
+		}
+		return strings.Join(parts, " ")
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := text(), text()
+		chunks := Words(a, b)
+		if ga, gb := sides(chunks); ga != a || gb != b {
+			t.Fatalf("Words(%q, %q) does not reproduce its inputs: got %q, %q", a, b, ga, gb)
+		}
+		if got, want := equalTokens(chunks), lcs(tokenize(a), tokenize(b)); got != want {
+			t.Fatalf("Words(%q, %q) keeps %d tokens, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestWordsLargeInput(t *testing.T) {
+	// Тексты без общих слов: правок больше maxEdits, середина заменяется целиком
+	var sa, sb strings.Builder
+	for i := 0; i < 5000; i++ {
+		sa.WriteString("x ")
+		sb.WriteString("y ")
+	}
+	a, b := "start "+sa.String()+"end", "start "+sb.String()+"end"
+
+	chunks := Words(a, b)
+	if ga, gb := sides(chunks); ga != a || gb != b {
+		t.Fatal("Words does not reproduce its inputs")
+	}
+	if len(chunks) != 4 {
+		t.Fatalf("got %d chunks, want equal/delete/insert/equal", len(chunks))
+	}
+
+	// Небольшая правка в длинном тексте находится точно
+	edited := strings.Replace(a, "x x x", "x z x", 1)
+	chunks = Words(a, edited)
+	if got, want := equalTokens(chunks), len(tokenize(a))-1; got != want {
+		t.Fatalf("keeps %d tokens, want %d", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+// Package jwt проверяет подписанные JWT (HS256 и RS256) без сторонних зависимостей.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed      = errors.New("malformed token")
+	ErrUnsupportedAlg = errors.New("unsupported signing algorithm")
+	ErrUnknownKey     = errors.New("no key to verify token")
+	ErrSignature      = errors.New("invalid token signature")
+	ErrExpired        = errors.New("token expired")
+	ErrNoExpiry       = errors.New("token has no expiration time")
+	ErrNotYetValid    = errors.New("token not valid yet")
+	ErrIssuer         = errors.New("unexpected token issuer")
+	ErrAudience       = errors.New("unexpected token audience")
+)
+
+// Claims — проверенные утверждения токена; Raw хранит их целиком
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt *time.Time
+	NotBefore *time.Time
+	Raw       map[string]any
+}
+
+// String возвращает строковое утверждение или пустую строку
+func (c *Claims) String(name string) string {
+	s, _ := c.Raw[name].(string)
+	return s
+}
+
+// Strings возвращает утверждение-список строк; одиночная строка тоже считается списком
+func (c *Claims) Strings(name string) []string {
+	switch v := c.Raw[name].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+type Options struct {
+	// Issuer и Audience проверяются, только если заданы
+	Issuer   string
+	Audience string
+	// Leeway — допустимое расхождение часов при проверке exp и nbf
+	Leeway time.Duration
+}
+
+type Verifier struct {
+	keys *KeySet
+	opts Options
+	now  func() time.Time
+}
+
+func NewVerifier(keys *KeySet, opts Options) *Verifier {
+	return &Verifier{keys: keys, opts: opts, now: time.Now}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify проверяет подпись и сроки действия токена и возвращает его утверждения
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformed, err)
+	}
+
+	if err := v.verifySignature(h, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrMalformed, err)
+	}
+
+	claims := &Claims{Raw: raw}
+	claims.Subject = claims.String("sub")
+	claims.Issuer = claims.String("iss")
+	claims.Audience = claims.Strings("aud")
+	if claims.ExpiresAt, err = numericDate(raw, "exp"); err != nil {
+		return nil, err
+	}
+	if claims.NotBefore, err = numericDate(raw, "nbf"); err != nil {
+		return nil, err
+	}
+
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature выбирает ключи строго по типу алгоритма, чтобы открытый
+// RSA-ключ нельзя было использовать как HMAC-секрет
+func (v *Verifier) verifySignature(h header, signed string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	var keys int
+	switch h.Alg {
+	case "HS256":
+		for _, secret := range v.keys.hmacKeys(h.Kid) {
+			keys++
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(signed))
+			if hmac.Equal(sig, mac.Sum(nil)) {
+				return nil
+			}
+		}
+	case "RS256":
+		for _, key := range v.keys.rsaKeys(h.Kid) {
+			keys++
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil {
+				return nil
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlg, h.Alg)
+	}
+
+	if keys == 0 {
+		return fmt.Errorf("%w: alg=%s kid=%q", ErrUnknownKey, h.Alg, h.Kid)
+	}
+	return ErrSignature
+}
+
+// validate проверяет сроки и адресатов; токен без exp не принимается — иначе он вечный
+func (v *Verifier) validate(c *Claims) error {
+	now := v.now()
+	if c.ExpiresAt == nil {
+		return ErrNoExpiry
+	}
+	if now.After(c.ExpiresAt.Add(v.opts.Leeway)) {
+		return ErrExpired
+	}
+	if c.NotBefore != nil && now.Before(c.NotBefore.Add(-v.opts.Leeway)) {
+		return ErrNotYetValid
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return fmt.Errorf("%w: %q", ErrIssuer, c.Issuer)
+	}
+	if v.opts.Audience != "" && !slices.Contains(c.Audience, v.opts.Audience) {
+		return ErrAudience
+	}
+	return nil
+}
+
+func decodeSegment(seg string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// numericDate читает утверждение-дату (секунды Unix); отсутствие — nil, не число — ошибка
+func numericDate(raw map[string]any, name string) (*time.Time, error) {
+	v, ok := raw[name]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must be a number", ErrMalformed, name)
+	}
+	t := time.Unix(0, int64(f*float64(time.Second)))
+	return &t, nil
+}
@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testNow    = time.Unix(1_700_000_000, 0)
+	testSecret = []byte("test-secret")
+)
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func segment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sign собирает токен; key — []byte для HS256, *rsa.PrivateKey для RS256, nil — без подписи
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	h := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		h["kid"] = kid
+	}
+	signed := segment(t, h) + "." + segment(t, claims)
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// validClaims — утверждения, которые проходят проверку; mutate меняет копию
+func validClaims(mutate func(map[string]any)) map[string]any {
+	c := map[string]any{
+		"sub": "user-1",
+		"iss": "https://sso.example",
+		"aud": []string{"commenttree"},
+		"exp": testNow.Add(time.Hour).Unix(),
+		"nbf": testNow.Add(-time.Minute).Unix(),
+	}
+	if mutate != nil {
+		mutate(c)
+	}
+	return c
+}
+
+func newTestVerifier(keys *KeySet) *Verifier {
+	v := NewVerifier(keys, Options{
+		Issuer:   "https://sso.example",
+		Audience: "commenttree",
+		Leeway:   30 * time.Second,
+	})
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey := testRSAKey(t)
+	otherRSA := testRSAKey(t)
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := NewKeySet()
+	keys.AddHMAC("", testSecret)
+	keys.AddRSA("rsa-1", &rsaKey.PublicKey)
+
+	rsaOnly := NewKeySet()
+	rsaOnly.AddRSA("rsa-1", &rsaKey.PublicKey)
+
+	tests := []struct {
+		name    string
+		keys    *KeySet
+		token   string
+		wantErr error
+	}{
+		{"hs256 valid", keys, sign(t, "HS256", "", testSecret, validClaims(nil)), nil},
+		{"rs256 valid", keys, sign(t, "RS256", "rsa-1", rsaKey, validClaims(nil)), nil},
+		{"single audience string", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["aud"] = "commenttree" })), nil},
+
+		// Подмена алгоритма: открытый RSA-ключ как HMAC-секрет и токен без подписи
+		{"rsa public key used as hmac secret", rsaOnly, sign(t, "HS256", "rsa-1", pubDER, validClaims(nil)), ErrUnknownKey},
+		{"rsa public key used as hmac secret with hmac keys", keys, sign(t, "HS256", "", pubDER, validClaims(nil)), ErrSignature},
+		{"alg none", keys, sign(t, "none", "", nil, validClaims(nil)), ErrUnsupportedAlg},
+		{"alg none uppercase", keys, sign(t, "NONE", "", nil, validClaims(nil)), ErrUnsupportedAlg},
+
+		{"unknown kid", rsaOnly, sign(t, "RS256", "rsa-2", otherRSA, validClaims(nil)), ErrUnknownKey},
+		{"rs256 without kid and no kid-less keys", rsaOnly, sign(t, "RS256", "", rsaKey, validClaims(nil)), ErrUnknownKey},
+		{"signed by another rsa key", keys, sign(t, "RS256", "rsa-1", otherRSA, validClaims(nil)), ErrSignature},
+		{"wrong hmac secret", keys, sign(t, "HS256", "", []byte("other"), validClaims(nil)), ErrSignature},
+		{"tampered payload", keys, tamper(sign(t, "HS256", "", testSecret, validClaims(nil)), segment(t, validClaims(func(c map[string]any) { c["sub"] = "admin" }))), ErrSignature},
+
+		{"expired beyond leeway", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["exp"] = testNow.Add(-31 * time.Second).Unix() })), ErrExpired},
+		{"expired within leeway", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["exp"] = testNow.Add(-29 * time.Second).Unix() })), nil},
+		{"missing exp", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { delete(c, "exp") })), ErrNoExpiry},
+		{"non-numeric exp", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["exp"] = "never" })), ErrMalformed},
+		{"null exp", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["exp"] = nil })), ErrMalformed},
+		{"not yet valid beyond leeway", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["nbf"] = testNow.Add(31 * time.Second).Unix() })), ErrNotYetValid},
+		{"not yet valid within leeway", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["nbf"] = testNow.Add(29 * time.Second).Unix() })), nil},
+		{"non-numeric nbf", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["nbf"] = "soon" })), ErrMalformed},
+		{"missing nbf", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { delete(c, "nbf") })), nil},
+
+		{"issuer mismatch", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["iss"] = "https://evil.example" })), ErrIssuer},
+		{"missing issuer", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { delete(c, "iss") })), ErrIssuer},
+		{"audience mismatch", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { c["aud"] = []string{"other-app"} })), ErrAudience},
+		{"missing audience", keys, sign(t, "HS256", "", testSecret, validClaims(func(c map[string]any) { delete(c, "aud") })), ErrAudience},
+
+		{"two segments", keys, "a.b", ErrMalformed},
+		{"bad signature encoding", keys, sign(t, "HS256", "", testSecret, validClaims(nil)) + "!", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := newTestVerifier(tt.keys).Verify(tt.token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %v, want nil", err)
+				}
+				if claims.Subject != "user-1" {
+					t.Fatalf("Subject = %q, want user-1", claims.Subject)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// tamper заменяет payload токена, оставляя заголовок и подпись
+func tamper(token, payload string) string {
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + payload + "." + parts[2]
+}
+
+func TestClaimsStrings(t *testing.T) {
+	c := &Claims{Raw: map[string]any{
+		"one":   "a",
+		"many":  []any{"a", 1.0, "b"},
+		"other": 42.0,
+	}}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"one", []string{"a"}},
+		{"many", []string{"a", "b"}},
+		{"other", nil},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		got := c.Strings(tt.name)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+			t.Errorf("Strings(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet — ключи проверки подписи. Токен проверяется ключами со своим kid, а если
+// таких нет (или kid в токене не указан) — ключами, добавленными без kid.
+// Ключ с другим kid токену не подбирается.
+type KeySet struct {
+	hmac map[string][][]byte
+	rsa  map[string][]*rsa.PublicKey
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{
+		hmac: make(map[string][][]byte),
+		rsa:  make(map[string][]*rsa.PublicKey),
+	}
+}
+
+func (ks *KeySet) AddHMAC(kid string, secret []byte) {
+	ks.hmac[kid] = append(ks.hmac[kid], secret)
+}
+
+func (ks *KeySet) AddRSA(kid string, key *rsa.PublicKey) {
+	ks.rsa[kid] = append(ks.rsa[kid], key)
+}
+
+func (ks *KeySet) Empty() bool {
+	return len(ks.hmac) == 0 && len(ks.rsa) == 0
+}
+
+func (ks *KeySet) hmacKeys(kid string) [][]byte {
+	return pick(ks.hmac, kid)
+}
+
+func (ks *KeySet) rsaKeys(kid string) []*rsa.PublicKey {
+	return pick(ks.rsa, kid)
+}
+
+// pick возвращает ключи с точным kid, а без совпадения — ключи без kid
+func pick[K any](keys map[string][]K, kid string) []K {
+	if k, ok := keys[kid]; ok {
+		return k
+	}
+	return keys[""]
+}
+
+// AddRSAPEMFile добавляет открытый RSA-ключ из PEM-файла (PKIX или PKCS#1)
+func (ks *KeySet) AddRSAPEMFile(kid, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read public key %s: %w", path, err)
+	}
+	key, err := ParseRSAPublicKeyPEM(data)
+	if err != nil {
+		return fmt.Errorf("public key %s: %w", path, err)
+	}
+	ks.AddRSA(kid, key)
+	return nil
+}
+
+func ParseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("not an RSA public key")
+		}
+		return rsaKey, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("certificate does not contain an RSA key")
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// LoadJWKSFile добавляет ключи из локального JWKS-файла: RSA (kty=RSA) и HMAC (kty=oct).
+// Ключи шифрования (use=enc) и неизвестных типов пропускаются.
+func (ks *KeySet) LoadJWKSFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read jwks %s: %w", path, err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("parse jwks %s: %w", path, err)
+	}
+
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			key, err := k.rsaPublicKey()
+			if err != nil {
+				return fmt.Errorf("jwks %s kid=%q: %w", path, k.Kid, err)
+			}
+			ks.AddRSA(k.Kid, key)
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return fmt.Errorf("jwks %s kid=%q: %w", path, k.Kid, err)
+			}
+			ks.AddHMAC(k.Kid, secret)
+		}
+	}
+	return nil
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decode modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decode exponent: %w", err)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() > 1<<31-1 || exp.Int64() < 3 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+}
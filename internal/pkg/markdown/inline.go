@@ -0,0 +1,233 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// htmlTag — сырой HTML-тег или комментарий; такие фрагменты вырезаются целиком
+	htmlTag = regexp.MustCompile(`^<(?:!--[\s\S]*?--|/?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?)>`)
+	bareURL = regexp.MustCompile(`^https?://[^\s<>()\[\]]+[^\s<>()\[\].,;:!?'"]`)
+
+	hrefUnescaper = strings.NewReplacer(`\(`, "(", `\)`, ")")
+)
+
+const escapable = "\\`*_[]()>#+-.!"
+
+const (
+	// maxDelimiterRun — самая длинная серия * или _, которая ещё может открыть или закрыть
+	// выделение (***a*** — это strong и em сразу); более длинная серия выводится как текст
+	maxDelimiterRun = 3
+	// maxLinkParens — глубина вложенности скобок в адресе ссылки, как в реализации CommonMark
+	maxLinkParens = 32
+)
+
+// renderInline рендерит выделение, код и ссылки внутри одной строки
+func renderInline(s string) string {
+	var b strings.Builder
+	inline(&b, s, true)
+	return b.String()
+}
+
+func inline(b *strings.Builder, s string, links bool) {
+	// noLinkBefore — до этой позиции ни одна [ не начинает ссылку
+	noLinkBefore := 0
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch c := s[i]; {
+		case c == '\\' && len(rest) > 1 && strings.IndexByte(escapable, rest[1]) >= 0:
+			b.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(rest[1:1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+
+		case c == '<':
+			if m := htmlTag.FindString(rest); m != "" {
+				i += len(m)
+				continue
+			}
+
+		case c == '[' && links && i >= noLinkBefore:
+			if text, href, n, ok := parseLink(rest); ok {
+				writeLink(b, href, func() { inline(b, text, false) })
+				i += n
+				continue
+			}
+			// Следующие [ до того же "](" упрутся в тот же неподходящий адрес — не разбираем его заново
+			noLinkBefore = len(s)
+			if end := strings.Index(rest, "]("); end >= 0 {
+				noLinkBefore = i + end
+			}
+
+		case c == 'h' && links && wordStart(s, i):
+			if m := bareURL.FindString(rest); m != "" {
+				writeLink(b, m, func() { b.WriteString(html.EscapeString(m)) })
+				i += len(m)
+				continue
+			}
+
+		case c == '*' || c == '_':
+			// Серия разделителей открывает выделение только целиком: если не вышло с её начала,
+			// вся серия — текст. Иначе каждый её символ заново искал бы закрывающий разделитель.
+			run := delimiterRun(s, i)
+			if run <= maxDelimiterRun {
+				if n := emphasis(b, s, i, links); n > 0 {
+					i += n
+					continue
+				}
+			}
+			b.WriteString(rest[:run])
+			i += run
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		b.WriteString(html.EscapeString(rest[:size]))
+		i += size
+	}
+}
+
+// emphasis пробует разобрать **strong** или *em* с позиции i и возвращает длину разобранного.
+// Подчёркивания внутри слов (snake_case) выделением не считаются.
+func emphasis(b *strings.Builder, s string, i int, links bool) int {
+	c := s[i]
+	if c == '_' && !wordStart(s, i) {
+		return 0
+	}
+
+	for _, width := range []int{2, 1} {
+		delim := strings.Repeat(string(c), width)
+		if !strings.HasPrefix(s[i:], delim) {
+			continue
+		}
+		body := s[i+width:]
+		if body == "" || body[0] == ' ' {
+			continue
+		}
+		end := strings.Index(body, delim)
+		// одиночный разделитель не должен совпасть с половиной двойного
+		for width == 1 && end >= 0 && end+1 < len(body) && body[end+1] == c {
+			next := strings.Index(body[end+2:], delim)
+			if next < 0 {
+				end = -1
+				break
+			}
+			end += next + 2
+		}
+		// в **bold *em*** тройку закрывают сначала одиночный, потом двойной разделитель:
+		// сдвигаемся, если внутри остался незакрытый одиночный
+		for width == 2 && end > 0 && end+2 < len(body) && body[end+2] == c && strings.Count(body[:end], string(c))%2 == 1 {
+			end++
+		}
+		if end <= 0 || body[end-1] == ' ' || delimiterRun(body, end) > maxDelimiterRun {
+			continue
+		}
+
+		tag := "em"
+		if width == 2 {
+			tag = "strong"
+		}
+		b.WriteString("<" + tag + ">")
+		inline(b, body[:end], links)
+		b.WriteString("</" + tag + ">")
+		return width*2 + end
+	}
+	return 0
+}
+
+// parseLink разбирает [text](href) и возвращает длину разобранного фрагмента
+func parseLink(s string) (text, href string, n int, ok bool) {
+	closeText := strings.Index(s, "](")
+	if closeText < 0 {
+		return "", "", 0, false
+	}
+	closeHref := linkDestinationEnd(s[closeText+2:])
+	if closeHref < 0 {
+		return "", "", 0, false
+	}
+	text = s[1:closeText]
+	href = hrefUnescaper.Replace(strings.TrimSpace(s[closeText+2 : closeText+2+closeHref]))
+	if text == "" || href == "" || strings.ContainsAny(href, " \t") {
+		return "", "", 0, false
+	}
+	return text, href, closeText + 3 + closeHref, true
+}
+
+// linkDestinationEnd возвращает позицию скобки, закрывающей адрес ссылки, или -1.
+// Как в CommonMark, адрес может содержать парные скобки (https://en.wikipedia.org/wiki/Go_(language))
+// и экранированные \( и \); пробел адрес завершает.
+func linkDestinationEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			if depth++; depth > maxLinkParens {
+				return -1
+			}
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ' ', '\t', '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// writeLink выводит ссылку только для безопасных схем; иначе остаётся один текст
+func writeLink(b *strings.Builder, href string, text func()) {
+	if !safeURL(href) {
+		text()
+		return
+	}
+	b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow ugc">`)
+	text()
+	b.WriteString("</a>")
+}
+
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// delimiterRun возвращает длину серии одинаковых символов, в которую входит s[i]
+func delimiterRun(s string, i int) int {
+	start, end := i, i+1
+	for start > 0 && s[start-1] == s[i] {
+		start--
+	}
+	for end < len(s) && s[end] == s[i] {
+		end++
+	}
+	return end - start
+}
+
+func wordStart(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
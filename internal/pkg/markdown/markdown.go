@@ -0,0 +1,109 @@
+// Package markdown рендерит подмножество Markdown для комментариев в безопасный HTML:
+// абзацы, цитаты, списки, блоки и фрагменты кода, выделение и ссылки.
+// Сырой HTML из текста удаляется, остальное экранируется, поэтому результат
+// можно вставлять в страницу без дополнительной санитизации.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	orderedItem = regexp.MustCompile(`^\d{1,9}[.)]\s+`)
+	bulletItem  = regexp.MustCompile(`^[-*+]\s+`)
+)
+
+// Render преобразует Markdown в HTML
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"))
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```"):
+			i++
+			start := i
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+				i++
+			}
+			b.WriteString("<pre><code>")
+			b.WriteString(html.EscapeString(strings.Join(lines[start:i], "\n")))
+			b.WriteString("</code></pre>\n")
+			i++ // закрывающий ``` (или конец текста)
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case bulletItem.MatchString(trimmed):
+			i = renderList(b, lines, i, "ul", bulletItem)
+
+		case orderedItem.MatchString(trimmed):
+			i = renderList(b, lines, i, "ol", orderedItem)
+
+		default:
+			var para []string
+			for ; i < len(lines) && !startsBlock(lines[i]); i++ {
+				para = append(para, renderInline(strings.TrimSpace(lines[i])))
+			}
+			b.WriteString("<p>")
+			b.WriteString(strings.Join(para, "<br>\n"))
+			b.WriteString("</p>\n")
+		}
+	}
+}
+
+// renderList выводит подряд идущие пункты одного списка; строки без маркера
+// продолжают предыдущий пункт
+func renderList(b *strings.Builder, lines []string, i int, tag string, marker *regexp.Regexp) int {
+	var items [][]string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			break
+		}
+		if loc := marker.FindStringIndex(trimmed); loc != nil {
+			items = append(items, []string{trimmed[loc[1]:]})
+			continue
+		}
+		if startsBlock(lines[i]) {
+			break
+		}
+		items[len(items)-1] = append(items[len(items)-1], trimmed)
+	}
+
+	b.WriteString("<" + tag + ">\n")
+	for _, item := range items {
+		parts := make([]string, len(item))
+		for j, p := range item {
+			parts[j] = renderInline(p)
+		}
+		b.WriteString("<li>" + strings.Join(parts, "<br>\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func startsBlock(line string) bool {
+	t := strings.TrimSpace(line)
+	return t == "" || strings.HasPrefix(t, "```") || strings.HasPrefix(t, ">") ||
+		bulletItem.MatchString(t) || orderedItem.MatchString(t)
+}
@@ -0,0 +1,109 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		// Ссылки: только http, https и mailto
+		{"http link", "[site](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow ugc">site</a></p>`},
+		{"mailto link", "[mail](mailto:a@example.com)", `<p><a href="mailto:a@example.com" rel="nofollow ugc">mail</a></p>`},
+		{"javascript link", "[click](javascript:alert(1))", `<p>click</p>`},
+		{"javascript link mixed case", "[click](JaVaScRiPt:alert`1`)", `<p>click</p>`},
+		{"data link", "[img](data:text/html;base64,PHNjcmlwdD4=)", `<p>img</p>`},
+		{"vbscript link", "[x](vbscript:msgbox)", `<p>x</p>`},
+		{"relative link", "[x](/admin)", `<p>x</p>`},
+		{"bare url", "see https://example.com/page.", `<p>see <a href="https://example.com/page" rel="nofollow ugc">https://example.com/page</a>.</p>`},
+		{"bare javascript url is text", "javascript:alert(1)", `<p>javascript:alert(1)</p>`},
+
+		// Кавычки и угловые скобки не выходят из значения атрибута
+		{"quote in href", `[x](https://e.com/"onmouseover="alert(1))`, `<p><a href="https://e.com/&#34;onmouseover=&#34;alert(1)" rel="nofollow ugc">x</a></p>`},
+		{"single quote in href", `[x](https://e.com/'x')`, `<p><a href="https://e.com/&#39;x&#39;" rel="nofollow ugc">x</a></p>`},
+
+		// Скобки в адресе: парные входят в адрес, лишняя закрывающая завершает ссылку
+		{"balanced parens in href", "[Go](https://en.wikipedia.org/wiki/Go_(language))", `<p><a href="https://en.wikipedia.org/wiki/Go_(language)" rel="nofollow ugc">Go</a></p>`},
+		{"nested parens in href", "[x](https://e.com/a(b(c)d)e)", `<p><a href="https://e.com/a(b(c)d)e" rel="nofollow ugc">x</a></p>`},
+		{"extra closing paren after link", "([x](https://e.com/a))", `<p>(<a href="https://e.com/a" rel="nofollow ugc">x</a>)</p>`},
+		{"escaped paren in href", `[x](https://e.com/a\)b)`, `<p><a href="https://e.com/a)b" rel="nofollow ugc">x</a></p>`},
+		{"unbalanced open paren", "[x](https://e.com/a(b)", `<p>[x](<a href="https://e.com/a" rel="nofollow ugc">https://e.com/a</a>(b)</p>`},
+		{"space ends href", "[x](/a (b))", `<p>[x](/a (b))</p>`},
+		{"markup in link text", "[<b>bold</b> & co](https://e.com)", `<p><a href="https://e.com" rel="nofollow ugc">bold &amp; co</a></p>`},
+
+		// Сырой HTML вырезается, остальное экранируется
+		{"script tag", "<script>alert(1)</script>", `<p>alert(1)</p>`},
+		{"tag with handler", `<img src=x onerror="alert(1)">`, `<p></p>`},
+		{"html comment", "a<!-- hidden -->b", `<p>ab</p>`},
+		{"lone angle brackets", "1 < 2 > 0 & done", `<p>1 &lt; 2 &gt; 0 &amp; done</p>`},
+		{"quotes are escaped", `say "hi" it's`, `<p>say &#34;hi&#34; it&#39;s</p>`},
+		{"escaped markup", `\*not em\*`, `<p>*not em*</p>`},
+
+		// Выделение и код
+		{"strong and em", "**bold** and *em*", `<p><strong>bold</strong> and <em>em</em></p>`},
+		{"em inside strong", "**bold *and em***", `<p><strong>bold <em>and em</em></strong></p>`},
+		{"strong followed by em", "**a***b*", `<p><strong>a</strong><em>b</em></p>`},
+		{"strong inside em", "*em **and bold***", `<p><em>em <strong>and bold</strong></em></p>`},
+		{"underscore emphasis", "_em_ and __strong__", `<p><em>em</em> and <strong>strong</strong></p>`},
+		{"snake case", "use snake_case_name here", `<p>use snake_case_name here</p>`},
+		{"unclosed emphasis", "2 * 3 = 6", `<p>2 * 3 = 6</p>`},
+		{"triple delimiter", "***both***", `<p><strong><em>both</em></strong></p>`},
+		{"long delimiter run is text", "a ***** b", `<p>a ***** b</p>`},
+		{"long run does not close emphasis", "*a*****", `<p>*a*****</p>`},
+		{"code span", "run `a < b && *c*`", `<p>run <code>a &lt; b &amp;&amp; *c*</code></p>`},
+		{"code span inside strong", "**see `x`**", `<p><strong>see <code>x</code></strong></p>`},
+		{"link inside emphasis", "*[x](https://e.com)*", `<p><em><a href="https://e.com" rel="nofollow ugc">x</a></em></p>`},
+		{"no links inside link text", "[a https://e.com](https://f.com)", `<p><a href="https://f.com" rel="nofollow ugc">a https://e.com</a></p>`},
+
+		// Блоки
+		{"code block", "```\n<b>x</b>\n**y**\n```", "<pre><code>&lt;b&gt;x&lt;/b&gt;\n**y**</code></pre>"},
+		{"quote", "> quoted *text*", "<blockquote>\n<p>quoted <em>text</em></p>\n</blockquote>"},
+		{"lists", "- a\n- b\n\n1. one\n2. two", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<ol>\n<li>one</li>\n<li>two</li>\n</ol>"},
+		{"paragraph line breaks", "one\r\ntwo\n\nthree", "<p>one<br>\ntwo</p>\n<p>three</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q)\n got: %s\nwant: %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// Длинные серии разделителей и скобок разбираются за линейное время:
+// при квадратичном разборе 100 тысяч символов заняли бы десятки секунд.
+func TestRenderPathological(t *testing.T) {
+	const n = 100_000
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"asterisks", strings.Repeat("*", n), "<p>" + strings.Repeat("*", n) + "</p>"},
+		{"asterisks between words", "a" + strings.Repeat("*", n) + "a", "<p>a" + strings.Repeat("*", n) + "a</p>"},
+		{"underscores", strings.Repeat("_", n), "<p>" + strings.Repeat("_", n) + "</p>"},
+		{"unclosed double delimiters", strings.Repeat("**a ", n/4), "<p>" + strings.Repeat("**a ", n/4-1) + "**a</p>"},
+		{"unclosed underscores", strings.Repeat("__a ", n/4), "<p>" + strings.Repeat("__a ", n/4-1) + "__a</p>"},
+		{"deep parens in href", "[x](" + strings.Repeat("(", n), "<p>[x](" + strings.Repeat("(", n) + "</p>"},
+		{"brackets before a broken href", strings.Repeat("[", n/2) + "](" + strings.Repeat("()", n/4), "<p>" + strings.Repeat("[", n/2) + "](" + strings.Repeat("()", n/4) + "</p>"},
+		{"brackets without href", strings.Repeat("[", n), "<p>" + strings.Repeat("[", n) + "</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			got := Render(tt.src)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("Render took %v", elapsed)
+			}
+			if got != tt.want {
+				t.Fatalf("Render() = %.80q..., want %.80q...", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Package mention находит упоминания @username в тексте комментария.
+package mention
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxMentions = 20
+	// maxNameLength — самое длинное имя; более длинное слово не упоминает никого, даже своё начало
+	maxNameLength = 64
+)
+
+var (
+	// Упоминание начинается в начале строки или после символа, который не может быть частью имени
+	// (так e-mail вида a@b.c не считается упоминанием)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.-])@([\p{L}\p{N}_][\p{L}\p{N}_.-]*)`)
+	fencedCode     = regexp.MustCompile("(?s)```.*?(```|$)")
+	inlineCode     = regexp.MustCompile("`[^`\n]*`")
+)
+
+// Extract возвращает уникальные имена из упоминаний в порядке появления, не больше maxMentions.
+// Упоминания внутри кода не учитываются.
+func Extract(text string) []string {
+	text = fencedCode.ReplaceAllString(text, " ")
+	text = inlineCode.ReplaceAllString(text, " ")
+
+	var names []string
+	seen := make(map[string]struct{})
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		// точка или дефис в конце — это пунктуация, а не часть имени
+		name := strings.TrimRight(m[1], ".-")
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
@@ -0,0 +1,75 @@
+package mention
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"none", "just text", nil},
+		{"single", "@alice look", []string{"alice"}},
+		{"several in order", "cc @bob and @alice", []string{"bob", "alice"}},
+		{"start of line", "first\n@alice second", []string{"alice"}},
+		{"unicode name", "спасибо, @мария!", []string{"мария"}},
+		{"dots and dashes inside", "@john.doe and @jane-roe", []string{"john.doe", "jane-roe"}},
+
+		// Пунктуация вокруг
+		{"trailing period", "ask @alice.", []string{"alice"}},
+		{"trailing comma", "@alice, @bob: hi", []string{"alice", "bob"}},
+		{"trailing dashes", "@alice-- no", []string{"alice"}},
+		{"in parentheses", "(@alice)", []string{"alice"}},
+		{"bare at", "@ alice and @.", nil},
+
+		// Не упоминания
+		{"email", "write to alice@example.com", nil},
+		{"email with dots", "a.b@c.d and x-y@z", nil},
+		{"double at", "@@alice", nil},
+		{"inline code", "run `@alice` then @bob", []string{"bob"}},
+		{"fenced code", "```\n@alice\n```\n@bob", []string{"bob"}},
+		{"unclosed fence hides the rest", "@bob\n```\n@alice", []string{"bob"}},
+
+		// Повторы и регистр
+		{"duplicates", "@alice @bob @alice", []string{"alice", "bob"}},
+		{"case is kept and distinguishes names", "@Alice @alice", []string{"Alice", "alice"}},
+		{"duplicate after trimming punctuation", "@alice. @alice", []string{"alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.in); !slices.Equal(got, tt.want) {
+				t.Fatalf("Extract(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < maxMentions+5; i++ {
+		fmt.Fprintf(&b, "@user%d ", i)
+	}
+	got := Extract(b.String())
+	if len(got) != maxMentions || got[0] != "user0" || got[maxMentions-1] != fmt.Sprintf("user%d", maxMentions-1) {
+		t.Fatalf("Extract() = %d names %q, want the first %d", len(got), got, maxMentions)
+	}
+
+	// Повторы не расходуют лимит
+	text := strings.Repeat("@alice ", 2*maxMentions) + "@bob"
+	if got := Extract(text); !slices.Equal(got, []string{"alice", "bob"}) {
+		t.Fatalf("Extract(repeated) = %q", got)
+	}
+
+	// Слишком длинное имя не упоминание — и не упоминание своего начала
+	if got := Extract("@" + strings.Repeat("я", maxNameLength)); len(got) != 1 {
+		t.Fatalf("Extract(longest) = %q, want one name", got)
+	}
+	if got := Extract("@" + strings.Repeat("a", maxNameLength+1) + " @bob"); !slices.Equal(got, []string{"bob"}) {
+		t.Fatalf("Extract(too long) = %q, want only bob", got)
+	}
+}
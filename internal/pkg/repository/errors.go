@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// foreignKeyErrors — что означает нарушение внешнего ключа для вызывающего
+var foreignKeyErrors = map[string]error{
+	"comments_parent_id_fkey":                    domain.ErrParentNotFound,
+	"comment_votes_comment_id_fkey":              domain.ErrCommentNotFound,
+	"comment_revisions_comment_id_fkey":          domain.ErrCommentNotFound,
+	"comment_mentions_comment_id_fkey":           domain.ErrCommentNotFound,
+	"notifications_comment_id_fkey":              domain.ErrCommentNotFound,
+	"webhook_deliveries_webhook_id_fkey":         domain.ErrWebhookNotFound,
+	"webhook_delivery_attempts_delivery_id_fkey": domain.ErrDeliveryNotFound,
+}
+
+// TranslateError превращает ошибку Postgres в доменную, сохраняя исходную в цепочке:
+// errors.Is(err, domain.ErrNotFound) работает, а в логах видна причина. Прочие ошибки не меняются.
+func TranslateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code.Name() {
+	case "foreign_key_violation":
+		if known, ok := foreignKeyErrors[pqErr.Constraint]; ok {
+			return fmt.Errorf("%w: %w", known, err)
+		}
+		return fmt.Errorf("%w: %w", &domain.Error{Kind: domain.ErrNotFound, Message: "referenced record not found"}, err)
+	case "unique_violation", "exclusion_violation":
+		return fmt.Errorf("%w: %w", domain.ErrAlreadyExists, err)
+	case "serialization_failure", "deadlock_detected", "lock_not_available":
+		return fmt.Errorf("%w: %w", domain.ErrConcurrentUpdate, err)
+	case "not_null_violation":
+		return fmt.Errorf("%w: %w", domain.Invalid(field(pqErr), "required"), err)
+	case "check_violation", "string_data_right_truncation", "numeric_value_out_of_range",
+		"invalid_text_representation", "character_not_in_repertoire", "untranslatable_character":
+		return fmt.Errorf("%w: %w", domain.Invalid(field(pqErr), "invalid value"), err)
+	}
+	return err
+}
+
+// field — поле, к которому относится ошибка: колонка, если Postgres её сообщил, иначе ограничение
+func field(e *pq.Error) string {
+	switch {
+	case e.Column != "":
+		return e.Column
+	case e.Constraint != "":
+		return e.Constraint
+	}
+	return "request"
+}
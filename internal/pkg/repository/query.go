@@ -2,43 +2,196 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/retry"
 	"github.com/wb-go/wbf/zlog"
 	"github.com/yokitheyo/CommentTree/internal/domain"
 )
 
+// QueryComments читает комментарии; внутри транзакции из ctx — через неё (см. Query)
 func QueryComments(ctx context.Context, db *dbpg.DB, strategy retry.Strategy, query string, args ...interface{}) ([]*domain.Comment, error) {
-	rows, err := db.QueryWithRetry(ctx, strategy, query, args...)
+	return collectRows(func() (*sql.Rows, error) {
+		return Query(ctx, db, strategy, query, args...)
+	}, "comment", ScanComment)
+}
+
+// QueryRankedComments читает комментарии, за колонками которых следует ключ сортировки (SortKeyExpr)
+func QueryRankedComments(ctx context.Context, db *dbpg.DB, strategy retry.Strategy, query string, args ...interface{}) ([]*domain.Comment, error) {
+	return collectRows(func() (*sql.Rows, error) {
+		return Query(ctx, db, strategy, query, args...)
+	}, "ranked comment", ScanRankedComment)
+}
+
+func QuerySearchHits(ctx context.Context, db *dbpg.DB, strategy retry.Strategy, query string, args ...interface{}) ([]*domain.SearchHit, error) {
+	return collectRows(func() (*sql.Rows, error) {
+		return Query(ctx, db, strategy, query, args...)
+	}, "search hit", ScanSearchHit)
+}
+
+func collectRows[T any](query func() (*sql.Rows, error), kind string, scan func(RowScanner) (T, error)) ([]T, error) {
+	rows, err := query()
 	if err != nil {
 		zlog.Logger.Error().Err(err).Msg("query failed")
-		return nil, fmt.Errorf("query comments: %w", err)
+		return nil, fmt.Errorf("query %s rows: %w", kind, err)
 	}
 	defer rows.Close()
 
-	var comments []*domain.Comment
+	var out []T
 	for rows.Next() {
-		c, err := ScanComment(rows)
+		v, err := scan(rows)
 		if err != nil {
 			zlog.Logger.Error().Err(err).Msg("scan failed")
-			return nil, fmt.Errorf("scan comment row: %w", err)
+			return nil, fmt.Errorf("scan %s row: %w", kind, err)
 		}
-		comments = append(comments, c)
+		out = append(out, v)
 	}
 
 	if err := rows.Err(); err != nil {
 		zlog.Logger.Error().Err(err).Msg("rows iteration failed")
-		return nil, fmt.Errorf("iterate comment rows: %w", err)
+		return nil, fmt.Errorf("iterate %s rows: %w", kind, err)
+	}
+
+	return out, nil
+}
+
+// SortKeyExpr возвращает SQL-выражение ключа сортировки по голосам (см. domain.SortKey).
+// alias задаёт префикс таблицы comments.
+func SortKeyExpr(sort, alias string) string {
+	col := func(name string) string {
+		if alias == "" {
+			return name
+		}
+		return alias + "." + name
+	}
+	up, down, score := col("upvotes"), col("downvotes"), col("score")
+	n := fmt.Sprintf("(%s + %s)", up, down)
+
+	switch sort {
+	case domain.SortTop:
+		return score + "::float8"
+	case domain.SortBest:
+		return fmt.Sprintf(`CASE WHEN %[3]s = 0 THEN 0::float8 ELSE
+			(%[1]s::float8 / %[3]s + 1.9208 / %[3]s - 1.96 * sqrt(%[1]s::float8 * %[2]s / %[3]s + 0.9604) / %[3]s)
+			/ (1 + 3.8416 / %[3]s) END`, up, down, n)
+	case domain.SortControversial:
+		return fmt.Sprintf(`CASE WHEN %[1]s = 0 OR %[2]s = 0 THEN 0::float8 ELSE
+			power(%[3]s::float8, LEAST(%[1]s, %[2]s)::float8 / GREATEST(%[1]s, %[2]s)) END`, up, down, n)
+	case domain.SortHot:
+		return fmt.Sprintf(`sign(%[1]s)::float8 * log(GREATEST(abs(%[1]s), 1)::float8)
+			+ (EXTRACT(EPOCH FROM %[2]s)::float8 - 1134028003) / 45000`, score, col("created_at"))
+	}
+	return "0::float8"
+}
+
+// VisibilityCond возвращает условие видимости комментариев для читателя, добавляя параметры в args.
+// Должно соответствовать domain.Viewer.CanSee.
+func VisibilityCond(alias string, v domain.Viewer, args *[]interface{}) string {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	if v.ModeratesAll() {
+		return "TRUE"
+	}
+
+	conds := []string{prefix + "status = 'approved'"}
+	if v.Subject != "" {
+		*args = append(*args, v.Subject)
+		conds = append(conds, fmt.Sprintf("(%sstatus = 'pending' AND %sauthor_subject = $%d)", prefix, prefix, len(*args)))
+	}
+	if v.IsModerator() {
+		*args = append(*args, pq.Array(v.Threads))
+		conds = append(conds, fmt.Sprintf("%sthread_key = ANY($%d)", prefix, len(*args)))
+	}
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	return "(" + strings.Join(conds, " OR ") + ")"
+}
+
+// TextSearchConfig — конфигурация полнотекстового поиска. Комментарии пишут на разных языках,
+// а словарная конфигурация (russian, english) стеммит только свой язык и портит слова остальных,
+// поэтому слова индексируются как есть, без стемминга. Конфигурация зашита и в триггер
+// comments_content_tsv_update: при её смене нужно пересоздать триггер и переиндексировать comments.
+const TextSearchConfig = "simple"
+
+// SearchVectorExpr возвращает выражение tsvector для строки comments, как в триггере индекса
+func SearchVectorExpr(alias string) string {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
 	}
+	return fmt.Sprintf(`setweight(to_tsvector('%[1]s', coalesce(%[2]scontent, '')), 'A') ||
+		setweight(to_tsvector('%[1]s', coalesce(%[2]sauthor, '')), 'B')`, TextSearchConfig, prefix)
+}
 
-	return comments, nil
+// HeadlineOptions собирает строку опций ts_headline с заданными маркерами подсветки
+func HeadlineOptions(start, stop string) string {
+	quote := func(v string) string {
+		return `"` + strings.ReplaceAll(v, `"`, "") + `"`
+	}
+	return fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \"",
+		quote(start), quote(stop))
+}
+
+// HTMLEscapeExpr возвращает SQL-выражение, экранирующее HTML в колонке col.
+// Текст экранируется до ts_headline, чтобы разметкой в сниппете были только маркеры подсветки
+func HTMLEscapeExpr(col string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`, col)
+}
+
+// TSQueryFunc возвращает функцию PostgreSQL, строящую tsquery для режима поиска
+func TSQueryFunc(mode domain.SearchMode) string {
+	switch mode {
+	case domain.SearchModePlain:
+		return "plainto_tsquery"
+	case domain.SearchModePhrase:
+		return "phraseto_tsquery"
+	default:
+		return "websearch_to_tsquery"
+	}
 }
 
 func OrderByCreated(sort string) string {
 	if sort == "desc" {
-		return "created_at DESC"
+		return "created_at DESC, id DESC"
+	}
+	return "created_at ASC, id ASC"
+}
+
+// KeysetScan определяет, от какого курсора и в каком направлении читать страницу.
+// Для Before выборка читается в обратном порядке, и её нужно развернуть (reverse = true).
+func KeysetScan(desc bool, page domain.PageRequest) (cur *domain.Cursor, scanDesc bool, reverse bool) {
+	if page.Before != nil {
+		return page.Before, !desc, true
+	}
+	return page.After, desc, false
+}
+
+// Direction возвращает ключевое слово направления сортировки
+func Direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// KeysetOperator возвращает оператор сравнения кортежей для чтения после курсора
+func KeysetOperator(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+// Reverse разворачивает срез на месте
+func Reverse[T any](items []T) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
 	}
-	return "created_at ASC"
 }
@@ -3,31 +3,125 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/yokitheyo/CommentTree/internal/domain"
 )
 
+// commentFields колонки comments в том порядке, в котором их ожидает ScanComment
+var commentFields = []string{
+	"id", "parent_id", "thread_key", "author", "content", "created_at", "updated_at", "deleted",
+	"edited_by", "edited_at", "upvotes", "downvotes", "score",
+	"status", "moderation_reason", "moderated_by", "moderated_at", "content_html", "locked", "author_subject",
+}
+
+// CommentColumns возвращает список колонок для ScanComment; alias задаёт префикс таблицы
+func CommentColumns(alias string) string {
+	if alias == "" {
+		return strings.Join(commentFields, ", ")
+	}
+	cols := make([]string, len(commentFields))
+	for i, f := range commentFields {
+		cols[i] = alias + "." + f
+	}
+	return strings.Join(cols, ", ")
+}
+
 // RowScanner интерфейс для сканирования строк (покрывает *sql.Row и *sql.Rows)
 type RowScanner interface {
 	Scan(dest ...interface{}) error
 }
 
+// commentRow собирает nullable-колонки комментария перед переносом в domain.Comment
+type commentRow struct {
+	c        *domain.Comment
+	parent   sql.NullInt64
+	updated  sql.NullTime
+	editedBy sql.NullString
+	editedAt sql.NullTime
+
+	moderationReason sql.NullString
+	moderatedBy      sql.NullString
+	moderatedAt      sql.NullTime
+	contentHTML      sql.NullString
+	authorSubject    sql.NullString
+}
+
+func newCommentRow() *commentRow {
+	return &commentRow{c: &domain.Comment{}}
+}
+
+func (r *commentRow) dest() []interface{} {
+	return []interface{}{
+		&r.c.ID, &r.parent, &r.c.ThreadKey, &r.c.Author, &r.c.Content, &r.c.CreatedAt, &r.updated, &r.c.Deleted,
+		&r.editedBy, &r.editedAt, &r.c.Upvotes, &r.c.Downvotes, &r.c.Score,
+		&r.c.Status, &r.moderationReason, &r.moderatedBy, &r.moderatedAt, &r.contentHTML, &r.c.Locked, &r.authorSubject,
+	}
+}
+
+func (r *commentRow) comment() *domain.Comment {
+	if r.parent.Valid {
+		r.c.ParentID = &r.parent.Int64
+	}
+	if r.updated.Valid {
+		r.c.UpdatedAt = &r.updated.Time
+	}
+	if r.editedBy.Valid {
+		r.c.EditedBy = &r.editedBy.String
+	}
+	if r.editedAt.Valid {
+		r.c.EditedAt = &r.editedAt.Time
+	}
+	if r.moderationReason.Valid {
+		r.c.ModerationReason = &r.moderationReason.String
+	}
+	if r.moderatedBy.Valid {
+		r.c.ModeratedBy = &r.moderatedBy.String
+	}
+	r.c.ContentHTML = r.contentHTML.String
+	r.c.AuthorSubject = r.authorSubject.String
+	if r.moderatedAt.Valid {
+		r.c.ModeratedAt = &r.moderatedAt.Time
+	}
+	return r.c
+}
+
 // ScanComment сканирует комментарий из строки БД
 func ScanComment(row RowScanner) (*domain.Comment, error) {
-	c := &domain.Comment{}
-	var parent sql.NullInt64
-	var updated sql.NullTime
-
-	if err := row.Scan(&c.ID, &parent, &c.Author, &c.Content, &c.CreatedAt, &updated, &c.Deleted); err != nil {
+	r := newCommentRow()
+	if err := row.Scan(r.dest()...); err != nil {
 		return nil, fmt.Errorf("scan comment: %w", err)
 	}
+	return r.comment(), nil
+}
+
+// ScanRankedComment сканирует комментарий и следующий за ним ключ сортировки
+func ScanRankedComment(row RowScanner) (*domain.Comment, error) {
+	r := newCommentRow()
+	if err := row.Scan(append(r.dest(), &r.c.SortKey)...); err != nil {
+		return nil, fmt.Errorf("scan ranked comment: %w", err)
+	}
+	return r.comment(), nil
+}
+
+// ScanSearchHit сканирует комментарий вместе с рангом, сниппетом и признаками совпадения полей
+func ScanSearchHit(row RowScanner) (*domain.SearchHit, error) {
+	r := newCommentRow()
+	h := &domain.SearchHit{}
+	var contentMatch, authorMatch bool
+
+	dest := append(r.dest(), &h.Rank, &h.Snippet, &contentMatch, &authorMatch)
+	if err := row.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("scan search hit: %w", err)
+	}
 
-	if parent.Valid {
-		c.ParentID = &parent.Int64
+	h.Comment = r.comment()
+	if contentMatch {
+		h.MatchedFields = append(h.MatchedFields, domain.MatchedFieldContent)
 	}
-	if updated.Valid {
-		c.UpdatedAt = &updated.Time
+	if authorMatch {
+		h.MatchedFields = append(h.MatchedFields, domain.MatchedFieldAuthor)
 	}
 
-	return c, nil
+	return h, nil
 }
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
+)
+
+type txKey struct{}
+
+// Executor — общие методы *sql.DB и *sql.Tx
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// TxFromContext возвращает транзакцию, открытую InTx выше по стеку
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// InTx выполняет fn в транзакции на мастере. Если в ctx уже есть транзакция, fn работает
+// в ней, а фиксирует или откатывает её тот, кто открыл.
+func InTx(ctx context.Context, db *dbpg.DB, fn func(ctx context.Context) error) (err error) {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Conn возвращает транзакцию из ctx, а без неё — мастер
+func Conn(ctx context.Context, db *dbpg.DB) Executor {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db.Master
+}
+
+// Query выполняет чтение: внутри транзакции — в ней, без повторов (ошибка всё равно
+// прерывает транзакцию), иначе — с повторами на реплике или мастере
+func Query(ctx context.Context, db *dbpg.DB, strategy retry.Strategy, query string, args ...interface{}) (*sql.Rows, error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+	return db.QueryWithRetry(ctx, strategy, query, args...)
+}
@@ -3,9 +3,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/retry"
 	"github.com/wb-go/wbf/zlog"
@@ -24,20 +27,37 @@ func NewCommentRepository(db *dbpg.DB, strategy retry.Strategy) domain.CommentRe
 
 func (r *commentRepository) Save(ctx context.Context, c *domain.Comment) error {
 	query := `
-    INSERT INTO comments (parent_id, author, content, deleted)
-    VALUES ($1, $2, $3, $4)
+    INSERT INTO comments (parent_id, thread_key, author, content, content_html, deleted, status, moderation_reason, moderated_by, moderated_at, management_token_hash, author_subject)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
     RETURNING id, created_at, updated_at
 `
-	err := r.db.Master.QueryRowContext(ctx, query,
-		c.ParentID,
-		c.Author,
-		c.Content,
-		c.Deleted,
-	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if c.Status == "" {
+		c.Status = domain.StatusApproved
+	}
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		if err := tx.QueryRowContext(ctx, query,
+			c.ParentID,
+			c.ThreadKey,
+			c.Author,
+			c.Content,
+			c.ContentHTML,
+			c.Deleted,
+			c.Status,
+			c.ModerationReason,
+			c.ModeratedBy,
+			c.ModeratedAt,
+			c.ManagementTokenHash,
+			c.AuthorSubject,
+		).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return err
+		}
+		return writeOutbox(ctx, tx, domain.EventCommentCreated, c, false)
+	})
 
 	if err != nil {
 		zlog.Logger.Error().Err(err).Str("author", c.Author).Msg("repository: Save comment failed")
-		return fmt.Errorf("save comment: %w", err)
+		return fmt.Errorf("save comment: %w", repository.TranslateError(err))
 	}
 
 	log := zlog.Logger.Debug().Int64("comment_id", c.ID)
@@ -49,16 +69,16 @@ func (r *commentRepository) Save(ctx context.Context, c *domain.Comment) error {
 }
 
 func (r *commentRepository) FindByID(ctx context.Context, id int64) (*domain.Comment, error) {
-	query := `
-		SELECT id, parent_id, author, content, created_at, updated_at, deleted
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM comments
 		WHERE id = $1
-	`
+	`, repository.CommentColumns(""))
 
-	row := r.db.Master.QueryRowContext(ctx, query, id)
+	row := repository.Conn(ctx, r.db).QueryRowContext(ctx, query, id)
 	c, err := repository.ScanComment(row)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			zlog.Logger.Debug().Int64("comment_id", id).Msg("comment not found")
 			return nil, fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
 		}
@@ -70,86 +90,672 @@ func (r *commentRepository) FindByID(ctx context.Context, id int64) (*domain.Com
 	return c, nil
 }
 
-func (r *commentRepository) FindChildren(ctx context.Context, parentID *int64, limit, offset int, sort string) ([]*domain.Comment, error) {
-	var query string
+func (r *commentRepository) FindChildren(ctx context.Context, q domain.ThreadQuery) ([]*domain.Comment, error) {
+	scoreSort := domain.IsScoreSort(q.Sort)
+	cur, scanDesc, reverse := repository.KeysetScan(q.Sort == domain.SortDesc || scoreSort, q.Page)
+
 	var args []interface{}
+	conds := []string{"deleted = false"}
 
-	if parentID == nil {
-		query = fmt.Sprintf(`
-			SELECT id, parent_id, author, content, created_at, updated_at, deleted
-			FROM comments
-			WHERE parent_id IS NULL AND deleted = false
-			ORDER BY %s
-			LIMIT $1 OFFSET $2
-		`, repository.OrderByCreated(sort))
-		args = []interface{}{limit, offset}
+	// Обсуждение задано всегда: ключом или родителем (корневые без ключа отклоняет GetThread)
+	var threadCond string
+	if q.ParentID == nil {
+		if q.ThreadKey == "" {
+			return nil, fmt.Errorf("find children: %w", domain.Invalid("thread", "is required to list top-level comments"))
+		}
+		conds = append(conds, "parent_id IS NULL")
 	} else {
-		query = fmt.Sprintf(`
-			SELECT id, parent_id, author, content, created_at, updated_at, deleted
-			FROM comments
-			WHERE parent_id = $1 AND deleted = false
-			ORDER BY %s
-			LIMIT $2 OFFSET $3
-		`, repository.OrderByCreated(sort))
-		args = []interface{}{*parentID, limit, offset}
+		args = append(args, *q.ParentID)
+		conds = append(conds, fmt.Sprintf("parent_id = $%d", len(args)))
+		threadCond = fmt.Sprintf("l.thread_key = (SELECT thread_key FROM comments WHERE id = $%d)", len(args))
+	}
+	if q.ThreadKey != "" {
+		args = append(args, q.ThreadKey)
+		conds = append(conds, fmt.Sprintf("thread_key = $%d", len(args)))
+		threadCond = fmt.Sprintf("l.thread_key = $%d", len(args))
+	}
+	conds = append(conds, repository.VisibilityCond("", q.Viewer, &args))
+
+	// Удалённый комментарий остаётся в выдаче заглушкой, пока под ним есть живой комментарий,
+	// видимый читателю через видимых же предков — так же, как pruneInvisible и pruneDeleted
+	// обрезают дерево. Такие предки собираются одним проходом вверх от живых комментариев
+	// обсуждения до LIMIT, чтобы страница не оказалась короче запрошенной.
+	var with string
+	if !q.HideDeleted {
+		with = fmt.Sprintf(`WITH RECURSIVE alive AS (
+				SELECT l.parent_id AS id FROM comments l
+				WHERE l.parent_id IS NOT NULL AND NOT l.deleted AND %s AND %s
+				UNION
+				SELECT p.parent_id FROM comments p JOIN alive a ON p.id = a.id
+				WHERE p.parent_id IS NOT NULL AND %s
+			)`, threadCond, repository.VisibilityCond("l", q.Viewer, &args), repository.VisibilityCond("p", q.Viewer, &args))
+		conds[0] = "(deleted = false OR id IN (SELECT id FROM alive))"
 	}
 
-	log := zlog.Logger.Debug().Int("limit", limit).Int("offset", offset)
-	if parentID != nil {
-		log = log.Int64("parent_id", *parentID)
+	// Для сортировок по голосам ключ идёт первым в ORDER BY и в курсоре
+	dir := repository.Direction(scanDesc)
+	columns := repository.CommentColumns("")
+	keyset := "(created_at, id)"
+	orderBy := fmt.Sprintf("created_at %[1]s, id %[1]s", dir)
+	queryFn := repository.QueryComments
+	if scoreSort {
+		keyExpr := repository.SortKeyExpr(q.Sort, "")
+		columns += ", " + keyExpr
+		keyset = fmt.Sprintf("(%s, created_at, id)", keyExpr)
+		orderBy = fmt.Sprintf("%[2]s %[1]s, created_at %[1]s, id %[1]s", dir, keyExpr)
+		queryFn = repository.QueryRankedComments
+	}
+
+	offset := q.Page.Offset
+	if cur != nil {
+		var placeholders string
+		if scoreSort {
+			var score float64
+			if cur.Score != nil {
+				score = *cur.Score
+			}
+			args = append(args, score, cur.CreatedAt, cur.ID)
+			placeholders = fmt.Sprintf("($%d, $%d, $%d)", len(args)-2, len(args)-1, len(args))
+		} else {
+			args = append(args, cur.CreatedAt, cur.ID)
+			placeholders = fmt.Sprintf("($%d, $%d)", len(args)-1, len(args))
+		}
+		conds = append(conds, fmt.Sprintf("%s %s %s", keyset, repository.KeysetOperator(scanDesc), placeholders))
+		offset = 0
+	}
+
+	args = append(args, q.Page.Limit, offset)
+	query := fmt.Sprintf(`
+		%s
+		SELECT %s
+		FROM comments
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, with, columns, strings.Join(conds, " AND "), orderBy, len(args)-1, len(args))
+
+	log := zlog.Logger.Debug().Int("limit", q.Page.Limit).Int("offset", offset).Str("sort", q.Sort).Bool("cursor", cur != nil)
+	if q.ParentID != nil {
+		log = log.Int64("parent_id", *q.ParentID)
 	}
 	log.Msg("repository: FindChildren query starting")
 
-	comments, err := repository.QueryComments(ctx, r.db, r.strategy, query, args...)
+	comments, err := queryFn(ctx, r.db, r.strategy, query, args...)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Interface("parent_id", parentID).Msg("repository: FindChildren failed")
-		return nil, fmt.Errorf("find children parent_id=%v: %w", parentID, err)
+		zlog.Logger.Error().Err(err).Interface("parent_id", q.ParentID).Msg("repository: FindChildren failed")
+		return nil, fmt.Errorf("find children parent_id=%v: %w", q.ParentID, err)
+	}
+	if reverse {
+		repository.Reverse(comments)
 	}
 
 	log = zlog.Logger.Debug().Int("count", len(comments))
-	if parentID != nil {
-		log = log.Int64("parent_id", *parentID)
+	if q.ParentID != nil {
+		log = log.Int64("parent_id", *q.ParentID)
 	}
 	log.Msg("repository: FindChildren completed")
 	return comments, nil
 }
 
-func (r *commentRepository) Delete(ctx context.Context, id int64) error {
-	zlog.Logger.Debug().Int64("comment_id", id).Msg("repository: Delete starting")
+func (r *commentRepository) FindSubtree(ctx context.Context, rootIDs []int64, maxDepth int) ([]*domain.Comment, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`
+		WITH RECURSIVE subtree AS (
+			SELECT %[1]s, 1 AS depth
+			FROM comments
+			WHERE parent_id = ANY($1)
+			UNION ALL
+			SELECT %[2]s, s.depth + 1
+			FROM comments c
+			JOIN subtree s ON c.parent_id = s.id
+			WHERE $2 <= 0 OR s.depth < $2
+		)
+		SELECT %[1]s
+		FROM subtree
+		ORDER BY created_at ASC, id ASC
+	`, repository.CommentColumns(""), repository.CommentColumns("c"))
+
+	zlog.Logger.Debug().Int("roots", len(rootIDs)).Int("max_depth", maxDepth).Msg("repository: FindSubtree query starting")
+
+	comments, err := repository.QueryComments(ctx, r.db, r.strategy, query, pq.Array(rootIDs), maxDepth)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int("roots", len(rootIDs)).Msg("repository: FindSubtree failed")
+		return nil, fmt.Errorf("find subtree roots=%v: %w", rootIDs, err)
+	}
+
+	zlog.Logger.Debug().Int("roots", len(rootIDs)).Int("count", len(comments)).Msg("repository: FindSubtree completed")
+	return comments, nil
+}
+
+func (r *commentRepository) FindAncestors(ctx context.Context, id int64) ([]*domain.Comment, error) {
+	query := fmt.Sprintf(`
+		WITH RECURSIVE ancestors AS (
+			SELECT %[1]s, 0 AS depth
+			FROM comments
+			WHERE id = (SELECT parent_id FROM comments WHERE id = $1)
+			UNION ALL
+			SELECT %[2]s, a.depth + 1
+			FROM comments c
+			JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT %[1]s
+		FROM ancestors
+		ORDER BY depth DESC
+	`, repository.CommentColumns(""), repository.CommentColumns("c"))
+
+	comments, err := repository.QueryComments(ctx, r.db, r.strategy, query, id)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("repository: FindAncestors failed")
+		return nil, fmt.Errorf("find ancestors of comment id=%d: %w", id, err)
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Int("count", len(comments)).Msg("repository: FindAncestors completed")
+	return comments, nil
+}
+
+func (r *commentRepository) Update(ctx context.Context, edit *domain.Comment, editor string) (*domain.Comment, error) {
+	id := edit.ID
+	zlog.Logger.Debug().Int64("comment_id", id).Str("editor", editor).Msg("repository: Update starting")
+
+	var updated *domain.Comment
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		var (
+			prevContent, author string
+			prevEditor          sql.NullString
+			createdAt           time.Time
+			editedAt            sql.NullTime
+			deleted             bool
+		)
+
+		err := tx.QueryRowContext(ctx, `
+			SELECT content, author, edited_by, created_at, edited_at, deleted
+			FROM comments
+			WHERE id = $1
+			FOR UPDATE
+		`, id).Scan(&prevContent, &author, &prevEditor, &createdAt, &editedAt, &deleted)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("lock comment id=%d: %w", id, err)
+		}
+		if deleted {
+			return fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentDeleted)
+		}
+
+		// Предыдущая версия сохраняется с автором и временем, когда она была написана
+		revEditor, revAt := author, createdAt
+		if prevEditor.Valid {
+			revEditor = prevEditor.String
+		}
+		if editedAt.Valid {
+			revAt = editedAt.Time
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO comment_revisions (comment_id, revision, content, editor, created_at)
+			SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4
+			FROM comment_revisions
+			WHERE comment_id = $1
+		`, id, prevContent, revEditor, revAt); err != nil {
+			return fmt.Errorf("save revision for comment id=%d: %w", id, err)
+		}
+
+		row := tx.QueryRowContext(ctx, fmt.Sprintf(`
+			UPDATE comments
+			SET content = $2, content_html = $4, edited_by = $3, edited_at = now(), updated_at = now(),
+				status = $5, moderation_reason = $6, moderated_by = $7, moderated_at = $8
+			WHERE id = $1
+			RETURNING %s
+		`, repository.CommentColumns("")), id, edit.Content, editor, edit.ContentHTML,
+			edit.Status, edit.ModerationReason, edit.ModeratedBy, edit.ModeratedAt)
+
+		updated, err = repository.ScanComment(row)
+		if err != nil {
+			return fmt.Errorf("update comment id=%d: %w", id, err)
+		}
+		return writeOutbox(ctx, tx, domain.EventCommentEdited, updated, false)
+	})
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("repository: Update failed")
+		return nil, repository.TranslateError(err)
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Msg("comment updated, revision saved")
+	return updated, nil
+}
+
+func (r *commentRepository) Vote(ctx context.Context, commentID int64, voter string, value int) (*domain.Comment, error) {
+	zlog.Logger.Debug().Int64("comment_id", commentID).Str("voter", voter).Int("value", value).Msg("repository: Vote starting")
+
+	var voted *domain.Comment
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		var deleted bool
+		err := tx.QueryRowContext(ctx, `SELECT deleted FROM comments WHERE id = $1 FOR UPDATE`, commentID).Scan(&deleted)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("comment id=%d: %w", commentID, domain.ErrCommentNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("lock comment id=%d: %w", commentID, err)
+		}
+		if deleted {
+			return fmt.Errorf("comment id=%d: %w", commentID, domain.ErrCommentDeleted)
+		}
+
+		var prev int
+		err = tx.QueryRowContext(ctx, `
+			SELECT value FROM comment_votes WHERE comment_id = $1 AND voter = $2
+		`, commentID, voter).Scan(&prev)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("find vote comment id=%d: %w", commentID, err)
+		}
+
+		if value == 0 {
+			_, err = tx.ExecContext(ctx, `DELETE FROM comment_votes WHERE comment_id = $1 AND voter = $2`, commentID, voter)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO comment_votes (comment_id, voter, value)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (comment_id, voter) DO UPDATE SET value = EXCLUDED.value, created_at = now()
+			`, commentID, voter, value)
+		}
+		if err != nil {
+			return fmt.Errorf("store vote comment id=%d: %w", commentID, err)
+		}
+
+		// Счётчики сдвигаются на разницу между прежним и новым голосом
+		upDelta, downDelta := voteCount(value, 1)-voteCount(prev, 1), voteCount(value, -1)-voteCount(prev, -1)
+		row := tx.QueryRowContext(ctx, fmt.Sprintf(`
+			UPDATE comments
+			SET upvotes = upvotes + $2, downvotes = downvotes + $3, score = score + $2 - $3
+			WHERE id = $1
+			RETURNING %s
+		`, repository.CommentColumns("")), commentID, upDelta, downDelta)
+
+		voted, err = repository.ScanComment(row)
+		if err != nil {
+			return fmt.Errorf("update score comment id=%d: %w", commentID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", commentID).Msg("repository: Vote failed")
+		return nil, repository.TranslateError(err)
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", commentID).Int("score", voted.Score).Msg("vote stored")
+	return voted, nil
+}
+
+func voteCount(value, side int) int {
+	if value == side {
+		return 1
+	}
+	return 0
+}
+
+func (r *commentRepository) ListRevisions(ctx context.Context, commentID int64) ([]*domain.Revision, error) {
+	rows, err := repository.Query(ctx, r.db, r.strategy, `
+		SELECT comment_id, revision, content, editor, created_at
+		FROM comment_revisions
+		WHERE comment_id = $1
+		ORDER BY revision ASC
+	`, commentID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", commentID).Msg("repository: ListRevisions failed")
+		return nil, fmt.Errorf("list revisions comment id=%d: %w", commentID, err)
+	}
+	defer rows.Close()
+
+	var revisions []*domain.Revision
+	for rows.Next() {
+		rev := &domain.Revision{}
+		if err := rows.Scan(&rev.CommentID, &rev.Number, &rev.Content, &rev.Editor, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan revision row: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate revision rows: %w", err)
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", commentID).Int("count", len(revisions)).Msg("repository: ListRevisions completed")
+	return revisions, nil
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id int64, cascade bool) (int64, error) {
+	return r.setDeleted(ctx, id, true, cascade)
+}
+
+func (r *commentRepository) Restore(ctx context.Context, id int64, cascade bool) (int64, error) {
+	return r.setDeleted(ctx, id, false, cascade)
+}
+
+// setDeleted меняет флаг deleted у комментария, а при cascade — у всего поддерева одним запросом.
+// Строки, удалённые одним запросом, получают общий deleted_batch; каскадное восстановление
+// возвращает только строки из пачки самого комментария. Возвращает число реально изменённых строк.
+func (r *commentRepository) setDeleted(ctx context.Context, id int64, deleted, cascade bool) (int64, error) {
+	op := "Delete"
+	if !deleted {
+		op = "Restore"
+	}
+	zlog.Logger.Debug().Int64("comment_id", id).Bool("cascade", cascade).Msgf("repository: %s starting", op)
+
+	const subtree = `subtree AS (
+		SELECT id FROM comments WHERE id = $1
+		UNION ALL
+		SELECT c.id FROM comments c JOIN subtree s ON c.parent_id = s.id
+	)`
+	var query string
+	switch {
+	case deleted && !cascade:
+		query = `
+			UPDATE comments
+			SET deleted = true, deleted_batch = nextval('comment_delete_batches'), updated_at = $2
+			WHERE id = $1 AND NOT deleted
+		`
+	case deleted:
+		// CTE вычисляется один раз, поэтому номер пачки у всех строк общий
+		query = `
+			WITH RECURSIVE ` + subtree + `, batch AS (
+				SELECT nextval('comment_delete_batches') AS n
+			)
+			UPDATE comments
+			SET deleted = true, deleted_batch = (SELECT n FROM batch), updated_at = $2
+			WHERE id IN (SELECT id FROM subtree) AND NOT deleted
+		`
+	case !cascade:
+		query = `
+			UPDATE comments
+			SET deleted = false, deleted_batch = NULL, updated_at = $2
+			WHERE id = $1 AND deleted
+		`
+	default:
+		query = `
+			WITH RECURSIVE ` + subtree + `
+			UPDATE comments
+			SET deleted = false, deleted_batch = NULL, updated_at = $2
+			WHERE id IN (SELECT id FROM subtree) AND deleted
+				AND (id = $1 OR deleted_batch = (SELECT deleted_batch FROM comments WHERE id = $1 AND deleted))
+		`
+	}
+
+	event := domain.EventCommentDeleted
+	if !deleted {
+		event = domain.EventCommentRestored
+	}
+
+	var affected int64
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		res, err := tx.ExecContext(ctx, query, id, time.Now())
+		if err != nil {
+			return fmt.Errorf("set deleted=%t comment id=%d: %w", deleted, id, err)
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return fmt.Errorf("rows affected for comment id=%d: %w", id, err)
+		}
+		if affected == 0 {
+			return nil
+		}
+
+		row := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s FROM comments WHERE id = $1`, repository.CommentColumns("")), id)
+		c, err := repository.ScanComment(row)
+		if err != nil {
+			return fmt.Errorf("reload comment id=%d: %w", id, err)
+		}
+		return writeOutbox(ctx, tx, event, c, cascade)
+	})
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msgf("repository: %s failed", op)
+		return 0, repository.TranslateError(err)
+	}
+
+	zlog.Logger.Debug().Int64("comment_id", id).Int64("affected", affected).Bool("deleted", deleted).Msg("comment deleted flag updated")
+	return affected, nil
+}
 
-	res, err := r.db.ExecWithRetry(ctx, r.strategy, `
+func (r *commentRepository) SetLocked(ctx context.Context, id int64, locked bool) (*domain.Comment, error) {
+	query := fmt.Sprintf(`
 		UPDATE comments
-		SET deleted = true, updated_at = $2
+		SET locked = $2, updated_at = now()
 		WHERE id = $1
-	`, id, time.Now())
+		RETURNING %s
+	`, repository.CommentColumns(""))
 
+	var c *domain.Comment
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		var err error
+		if c, err = repository.ScanComment(tx.QueryRowContext(ctx, query, id, locked)); err != nil {
+			return err
+		}
+		return writeOutbox(ctx, tx, domain.EventCommentLocked, c, false)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
+	}
 	if err != nil {
-		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("repository: Delete failed")
-		return fmt.Errorf("delete comment id=%d: %w", id, err)
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Bool("locked", locked).Msg("repository: SetLocked failed")
+		return nil, fmt.Errorf("set locked=%t comment id=%d: %w", locked, id, repository.TranslateError(err))
 	}
 
-	zlog.Logger.Debug().Int64("comment_id", id).Interface("result", res).Msg("comment marked as deleted")
-	return nil
+	zlog.Logger.Debug().Int64("comment_id", id).Bool("locked", locked).Msg("comment locked flag updated")
+	return c, nil
 }
 
-func (r *commentRepository) Search(ctx context.Context, q string, limit, offset int) ([]*domain.Comment, error) {
-	query := `
-		SELECT id, parent_id, author, content, created_at, updated_at, deleted
+func (r *commentRepository) FindByStatus(ctx context.Context, status domain.ModerationStatus, threadKeys []string, page domain.PageRequest) ([]*domain.Comment, error) {
+	cur, scanDesc, reverse := repository.KeysetScan(false, page)
+
+	args := []interface{}{status}
+	conds := []string{"status = $1", "deleted = false"}
+	if len(threadKeys) > 0 {
+		args = append(args, pq.Array(threadKeys))
+		conds = append(conds, fmt.Sprintf("thread_key = ANY($%d)", len(args)))
+	}
+
+	offset := page.Offset
+	if cur != nil {
+		args = append(args, cur.CreatedAt, cur.ID)
+		conds = append(conds, fmt.Sprintf("(created_at, id) %s ($%d, $%d)",
+			repository.KeysetOperator(scanDesc), len(args)-1, len(args)))
+		offset = 0
+	}
+
+	args = append(args, page.Limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM comments
-		WHERE (content ILIKE '%' || $1 || '%' OR author ILIKE '%' || $1 || '%')
-		AND deleted = false
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+		WHERE %s
+		ORDER BY created_at %[3]s, id %[3]s
+		LIMIT $%[4]d OFFSET $%[5]d
+	`, repository.CommentColumns(""), strings.Join(conds, " AND "), repository.Direction(scanDesc), len(args)-1, len(args))
+
+	comments, err := repository.QueryComments(ctx, r.db, r.strategy, query, args...)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("status", string(status)).Msg("repository: FindByStatus failed")
+		return nil, fmt.Errorf("find comments status=%s: %w", status, err)
+	}
+	if reverse {
+		repository.Reverse(comments)
+	}
+
+	zlog.Logger.Debug().Str("status", string(status)).Int("count", len(comments)).Msg("repository: FindByStatus completed")
+	return comments, nil
+}
+
+func (r *commentRepository) SetStatus(ctx context.Context, ids []int64, status domain.ModerationStatus, moderator, reason string) ([]*domain.Comment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var reasonArg interface{}
+	if reason != "" {
+		reasonArg = reason
+	}
 
-	zlog.Logger.Debug().Str("search_query", q).Int("limit", limit).Int("offset", offset).Msg("repository: Search query starting")
+	query := fmt.Sprintf(`
+		UPDATE comments
+		SET status = $2, moderation_reason = $3, moderated_by = $4, moderated_at = now()
+		WHERE id = ANY($1)
+		RETURNING %s
+	`, repository.CommentColumns(""))
 
-	comments, err := repository.QueryComments(ctx, r.db, r.strategy, query, q, limit, offset)
+	var comments []*domain.Comment
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		// Внутри транзакции UPDATE ... RETURNING уходит на мастер, а не на реплику
+		var err error
+		comments, err = repository.QueryComments(ctx, r.db, r.strategy, query, pq.Array(ids), status, reasonArg, moderator)
+		if err != nil {
+			return err
+		}
+		for _, c := range comments {
+			if err := writeOutbox(ctx, tx, domain.EventCommentModerated, c, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		zlog.Logger.Error().Err(err).Str("search_query", q).Msg("repository: Search failed")
-		return nil, fmt.Errorf("search comments query=%q: %w", q, err)
+		zlog.Logger.Error().Err(err).Str("status", string(status)).Msg("repository: SetStatus failed")
+		return nil, fmt.Errorf("set status=%s ids=%v: %w", status, ids, repository.TranslateError(err))
 	}
 
-	zlog.Logger.Debug().Str("search_query", q).Int("count", len(comments)).Msg("repository: Search completed")
+	zlog.Logger.Debug().Str("status", string(status)).Int("count", len(comments)).Msg("repository: SetStatus completed")
 	return comments, nil
 }
+
+func (r *commentRepository) FindModerated(ctx context.Context, exclude string, limit int) ([]*domain.Comment, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM comments
+		WHERE status IN ('approved', 'rejected')
+		  AND moderated_by IS NOT NULL
+		  AND moderated_by <> $1
+		ORDER BY moderated_at DESC, id DESC
+		LIMIT $2
+	`, repository.CommentColumns(""))
+
+	comments, err := repository.QueryComments(ctx, r.db, r.strategy, query, exclude, limit)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("repository: FindModerated failed")
+		return nil, fmt.Errorf("find moderated comments: %w", err)
+	}
+
+	zlog.Logger.Debug().Int("count", len(comments)).Msg("repository: FindModerated completed")
+	return comments, nil
+}
+
+func (r *commentRepository) FindManagementTokenHash(ctx context.Context, id int64) ([]byte, error) {
+	var hash []byte
+	err := repository.Conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT management_token_hash FROM comments WHERE id = $1`, id).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCommentNotFound
+	}
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", id).Msg("repository: FindManagementTokenHash failed")
+		return nil, fmt.Errorf("find management token id=%d: %w", id, err)
+	}
+	return hash, nil
+}
+
+func (r *commentRepository) ListThreads(ctx context.Context, limit, offset int) ([]*domain.ThreadSummary, error) {
+	// thread_summaries поддерживает триггер: в ней только одобренные неудалённые комментарии
+	rows, err := repository.Query(ctx, r.db, r.strategy, `
+		SELECT thread_key, comment_count, last_comment_at
+		FROM thread_summaries
+		WHERE comment_count > 0
+		ORDER BY last_comment_at DESC, thread_key ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("repository: ListThreads failed")
+		return nil, fmt.Errorf("list threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []*domain.ThreadSummary
+	for rows.Next() {
+		t := &domain.ThreadSummary{}
+		if err := rows.Scan(&t.Key, &t.CommentCount, &t.LastCommentAt); err != nil {
+			return nil, fmt.Errorf("scan thread row: %w", err)
+		}
+		threads = append(threads, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate thread rows: %w", err)
+	}
+
+	zlog.Logger.Debug().Int("count", len(threads)).Msg("repository: ListThreads completed")
+	return threads, nil
+}
+
+func (r *commentRepository) Search(ctx context.Context, sq domain.SearchQuery) ([]*domain.SearchHit, error) {
+	// Релевантность сортируется по убыванию; для Before читаем в обратную сторону
+	cur, scanDesc, reverse := repository.KeysetScan(true, sq.Page)
+
+	args := []interface{}{sq.Text, repository.HeadlineOptions(sq.HighlightStart, sq.HighlightStop)}
+	conds := []string{"c.content_tsv @@ q", "c.deleted = false"}
+	if sq.ThreadKey != "" {
+		args = append(args, sq.ThreadKey)
+		conds = append(conds, fmt.Sprintf("c.thread_key = $%d", len(args)))
+	}
+	conds = append(conds, repository.VisibilityCond("c", sq.Viewer, &args))
+
+	offset := sq.Page.Offset
+	if cur != nil {
+		var score float64
+		if cur.Score != nil {
+			score = *cur.Score
+		}
+		args = append(args, score, cur.CreatedAt, cur.ID)
+		conds = append(conds, fmt.Sprintf("(ts_rank(c.content_tsv, q)::float8, c.created_at, c.id) %s ($%d, $%d, $%d)",
+			repository.KeysetOperator(scanDesc), len(args)-2, len(args)-1, len(args)))
+		offset = 0
+	}
+
+	args = append(args, sq.Page.Limit, offset)
+	dir := repository.Direction(scanDesc)
+	// Поле считается совпавшим, если в нём есть хотя бы одно слово запроса: слова могут
+	// разойтись по тексту и автору, и тогда весь запрос не совпадает ни с одним полем.
+	// querytree отбрасывает исключённые слова (-word), так что они совпадением не считаются
+	query := fmt.Sprintf(`
+		SELECT %[6]s,
+			h.rank,
+			ts_headline('%[9]s', %[8]s, h.q, $2),
+			tsvector_to_array(to_tsvector('%[9]s', h.content)) && tsvector_to_array(to_tsvector('%[9]s', querytree(h.q))),
+			tsvector_to_array(to_tsvector('%[9]s', h.author)) && tsvector_to_array(to_tsvector('%[9]s', querytree(h.q)))
+		FROM (
+			SELECT %[7]s,
+				q, ts_rank(c.content_tsv, q)::float8 AS rank
+			FROM comments c, %[1]s('%[9]s', $1) AS q
+			WHERE %[2]s
+			ORDER BY rank %[3]s, c.created_at %[3]s, c.id %[3]s
+			LIMIT $%[4]d OFFSET $%[5]d
+		) h
+		ORDER BY h.rank %[3]s, h.created_at %[3]s, h.id %[3]s
+	`, repository.TSQueryFunc(sq.Mode), strings.Join(conds, " AND "), dir, len(args)-1, len(args),
+		repository.CommentColumns("h"), repository.CommentColumns("c"), repository.HTMLEscapeExpr("h.content"),
+		repository.TextSearchConfig)
+
+	zlog.Logger.Debug().Str("search_query", sq.Text).Str("mode", string(sq.Mode)).Int("limit", sq.Page.Limit).Int("offset", offset).Bool("cursor", cur != nil).Msg("repository: Search query starting")
+
+	hits, err := repository.QuerySearchHits(ctx, r.db, r.strategy, query, args...)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("search_query", sq.Text).Msg("repository: Search failed")
+		return nil, fmt.Errorf("search comments query=%q: %w", sq.Text, err)
+	}
+	if reverse {
+		repository.Reverse(hits)
+	}
+
+	zlog.Logger.Debug().Str("search_query", sq.Text).Int("count", len(hits)).Msg("repository: Search completed")
+	return hits, nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/pkg/repository"
+)
+
+// excerptLength — сколько символов комментария показывать в уведомлении
+const excerptLength = 200
+
+type notificationRepository struct {
+	db       *dbpg.DB
+	strategy retry.Strategy
+}
+
+func NewNotificationRepository(db *dbpg.DB, strategy retry.Strategy) domain.NotificationRepository {
+	return &notificationRepository{db: db, strategy: strategy}
+}
+
+func (r *notificationRepository) SaveMentions(ctx context.Context, commentID int64, usernames []string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	_, err := repository.Conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO comment_mentions (comment_id, username)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING
+	`, commentID, pq.Array(usernames))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("comment_id", commentID).Msg("repository: SaveMentions failed")
+		return fmt.Errorf("save mentions for comment id=%d: %w", commentID, repository.TranslateError(err))
+	}
+	return nil
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notifications []*domain.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	recipients := make([]string, len(notifications))
+	kinds := make([]string, len(notifications))
+	commentIDs := make([]int64, len(notifications))
+	actors := make([]string, len(notifications))
+	for i, n := range notifications {
+		recipients[i], kinds[i], commentIDs[i], actors[i] = n.Recipient, string(n.Kind), n.CommentID, n.Actor
+	}
+
+	_, err := repository.Conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO notifications (recipient, kind, comment_id, actor)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::bigint[], $4::text[])
+		ON CONFLICT (recipient, kind, comment_id) DO NOTHING
+	`, pq.Array(recipients), pq.Array(kinds), pq.Array(commentIDs), pq.Array(actors))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int("count", len(notifications)).Msg("repository: Create notifications failed")
+		return fmt.Errorf("create notifications: %w", repository.TranslateError(err))
+	}
+
+	zlog.Logger.Debug().Int("count", len(notifications)).Msg("repository: notifications created")
+	return nil
+}
+
+func (r *notificationRepository) List(ctx context.Context, recipient string, unreadOnly bool, page domain.PageRequest) ([]*domain.Notification, error) {
+	cur, scanDesc, reverse := repository.KeysetScan(true, page)
+
+	args := []interface{}{recipient}
+	conds := []string{"n.recipient = $1"}
+	if unreadOnly {
+		conds = append(conds, "n.read_at IS NULL")
+	}
+
+	offset := page.Offset
+	if cur != nil {
+		args = append(args, cur.CreatedAt, cur.ID)
+		conds = append(conds, fmt.Sprintf("(n.created_at, n.id) %s ($%d, $%d)",
+			repository.KeysetOperator(scanDesc), len(args)-1, len(args)))
+		offset = 0
+	}
+
+	args = append(args, page.Limit, offset)
+	query := fmt.Sprintf(`
+		SELECT n.id, n.recipient, n.kind, n.comment_id, c.thread_key, n.actor,
+		       CASE WHEN c.deleted OR c.status <> 'approved' THEN '' ELSE left(c.content, %[5]d) END,
+		       n.created_at, n.read_at
+		FROM notifications n
+		JOIN comments c ON c.id = n.comment_id
+		WHERE %[1]s
+		ORDER BY n.created_at %[2]s, n.id %[2]s
+		LIMIT $%[3]d OFFSET $%[4]d
+	`, strings.Join(conds, " AND "), repository.Direction(scanDesc), len(args)-1, len(args), excerptLength)
+
+	rows, err := repository.Query(ctx, r.db, r.strategy, query, args...)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("recipient", recipient).Msg("repository: List notifications failed")
+		return nil, fmt.Errorf("list notifications for %s: %w", recipient, err)
+	}
+	defer rows.Close()
+
+	var out []*domain.Notification
+	for rows.Next() {
+		var (
+			n      domain.Notification
+			readAt sql.NullTime
+		)
+		if err := rows.Scan(&n.ID, &n.Recipient, &n.Kind, &n.CommentID, &n.ThreadKey, &n.Actor,
+			&n.Excerpt, &n.CreatedAt, &readAt); err != nil {
+			return nil, fmt.Errorf("scan notification: %w", err)
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		out = append(out, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate notifications: %w", err)
+	}
+	if reverse {
+		repository.Reverse(out)
+	}
+
+	zlog.Logger.Debug().Str("recipient", recipient).Int("count", len(out)).Msg("repository: List notifications completed")
+	return out, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, recipient string) (int64, error) {
+	rows, err := repository.Query(ctx, r.db, r.strategy,
+		`SELECT COUNT(*) FROM notifications WHERE recipient = $1 AND read_at IS NULL`, recipient)
+	if err != nil {
+		return 0, fmt.Errorf("count unread notifications for %s: %w", recipient, err)
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, fmt.Errorf("scan unread count: %w", err)
+		}
+	}
+	return n, rows.Err()
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, recipient string, ids []int64) (int64, error) {
+	query := `UPDATE notifications SET read_at = now() WHERE recipient = $1 AND read_at IS NULL`
+	args := []interface{}{recipient}
+	if len(ids) > 0 {
+		query += ` AND id = ANY($2)`
+		args = append(args, pq.Array(ids))
+	}
+
+	res, err := repository.Conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("recipient", recipient).Msg("repository: MarkRead failed")
+		return 0, fmt.Errorf("mark notifications read for %s: %w", recipient, repository.TranslateError(err))
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/pkg/repository"
+)
+
+// outboxLockKey — ключ advisory-блокировки: очередь разбирает один экземпляр за раз,
+// поэтому события публикуются в порядке записи
+const outboxLockKey = 0x6f7574626f78 // "outbox"
+
+// outboxPayload — тело события в таблице outbox
+type outboxPayload struct {
+	Comment *domain.Comment `json:"comment"`
+	Subtree bool            `json:"subtree,omitempty"`
+}
+
+// writeOutbox записывает событие о комментарии в той же транзакции, что и само изменение.
+// В событие попадает снимок без секретов и детей; у удалённого — без содержимого.
+func writeOutbox(ctx context.Context, tx repository.Executor, typ domain.CommentEventType, c *domain.Comment, subtree bool) error {
+	snapshot := *c
+	snapshot.ManagementToken = ""
+	snapshot.ManagementTokenHash = nil
+	snapshot.Children = nil
+	if snapshot.Deleted {
+		snapshot.Tombstone()
+	}
+
+	payload, err := json.Marshal(outboxPayload{Comment: &snapshot, Subtree: subtree})
+	if err != nil {
+		return fmt.Errorf("encode outbox event: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, payload) VALUES ($1, $2)`, typ, string(payload)); err != nil {
+		return fmt.Errorf("write outbox event %s for comment id=%d: %w", typ, c.ID, err)
+	}
+	return nil
+}
+
+type outboxRepository struct {
+	db *dbpg.DB
+}
+
+func NewOutboxRepository(db *dbpg.DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Drain(ctx context.Context, limit int, deliver func(context.Context, *domain.CommentEvent) error) ([]*domain.CommentEvent, error) {
+	var events []*domain.CommentEvent
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		var locked bool
+		if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&locked); err != nil {
+			return fmt.Errorf("lock outbox: %w", err)
+		}
+		if !locked {
+			// Очередь разбирает другой экземпляр
+			return nil
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, event_id, event_type, payload, created_at
+			FROM outbox
+			WHERE published_at IS NULL
+			ORDER BY id
+			LIMIT $1
+		`, limit)
+		if err != nil {
+			return fmt.Errorf("read outbox: %w", err)
+		}
+
+		var ids []int64
+		for rows.Next() {
+			var (
+				id      int64
+				e       domain.CommentEvent
+				payload []byte
+				body    outboxPayload
+			)
+			if err := rows.Scan(&id, &e.ID, &e.Type, &payload, &e.At); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan outbox row: %w", err)
+			}
+			if err := json.Unmarshal(payload, &body); err != nil {
+				rows.Close()
+				return fmt.Errorf("decode outbox event id=%d: %w", id, err)
+			}
+			e.Seq, e.Comment, e.Subtree = id, body.Comment, body.Subtree
+			ids = append(ids, id)
+			events = append(events, &e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("iterate outbox: %w", err)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Записи deliver фиксируются вместе с отметкой: событие не отмечается без них
+		if deliver != nil {
+			for _, e := range events {
+				if err := deliver(ctx, e); err != nil {
+					return fmt.Errorf("deliver outbox event %s: %w", e.ID, err)
+				}
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE outbox SET published_at = now() WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			return fmt.Errorf("mark outbox published: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// Пачка откатилась целиком и будет разобрана заново с теми же ID
+		zlog.Logger.Error().Err(err).Msg("repository: Drain outbox failed")
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	res, err := repository.Conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < $1`, before)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("repository: Cleanup outbox failed")
+		return 0, fmt.Errorf("cleanup outbox: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/pkg/repository"
+)
+
+type searchIndexRepository struct {
+	db *dbpg.DB
+}
+
+func NewSearchIndexRepository(db *dbpg.DB) domain.SearchIndexRepository {
+	return &searchIndexRepository{db: db}
+}
+
+func (r *searchIndexRepository) IndexMissing(ctx context.Context, afterID int64, limit int) (int64, int, error) {
+	// Пачка выбирается по первичному ключу от afterID, чтобы не просматривать уже пройденное
+	query := fmt.Sprintf(`
+		WITH batch AS (
+			SELECT id FROM comments
+			WHERE id > $1 AND content_tsv IS NULL
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE
+		)
+		UPDATE comments
+		SET content_tsv = %s
+		FROM batch
+		WHERE comments.id = batch.id
+		RETURNING comments.id
+	`, repository.SearchVectorExpr("comments"))
+
+	rows, err := repository.Conn(ctx, r.db).QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("after_id", afterID).Msg("repository: IndexMissing failed")
+		return 0, 0, fmt.Errorf("index comments after id=%d: %w", afterID, err)
+	}
+	defer rows.Close()
+
+	lastID, indexed := afterID, 0
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return 0, 0, fmt.Errorf("scan indexed comment id: %w", err)
+		}
+		lastID = max(lastID, id)
+		indexed++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("iterate indexed comments: %w", err)
+	}
+	return lastID, indexed, nil
+}
@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/pkg/repository"
+)
+
+type transactor struct {
+	db *dbpg.DB
+}
+
+// NewTransactor открывает транзакции на мастере; все репозитории этого пакета
+// подхватывают транзакцию из ctx
+func NewTransactor(db *dbpg.DB) domain.Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return repository.InTx(ctx, t.db, fn)
+}
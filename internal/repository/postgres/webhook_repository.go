@@ -0,0 +1,343 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/retry"
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/pkg/repository"
+)
+
+const webhookColumns = `id, url, secret, events, active, created_at, updated_at`
+
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.attempts_before_replay, d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+type webhookRepository struct {
+	db       *dbpg.DB
+	strategy retry.Strategy
+}
+
+func NewWebhookRepository(db *dbpg.DB, strategy retry.Strategy) domain.WebhookRepository {
+	return &webhookRepository{db: db, strategy: strategy}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, w *domain.Webhook) error {
+	err := repository.Conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO webhooks (url, secret, events, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, w.URL, w.Secret, pq.Array(w.Events), w.Active).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("url", w.URL).Msg("repository: Create webhook failed")
+		return fmt.Errorf("create webhook: %w", repository.TranslateError(err))
+	}
+	return nil
+}
+
+func (r *webhookRepository) List(ctx context.Context) ([]*domain.Webhook, error) {
+	rows, err := repository.Query(ctx, r.db, r.strategy, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("repository: List webhooks failed")
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook: %w", err)
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, id int64) (*domain.Webhook, error) {
+	row := repository.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id)
+	w, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("webhook id=%d: %w", id, domain.ErrWebhookNotFound)
+	}
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("webhook_id", id).Msg("repository: FindByID webhook failed")
+		return nil, fmt.Errorf("find webhook id=%d: %w", id, err)
+	}
+	return w, nil
+}
+
+func (r *webhookRepository) Update(ctx context.Context, w *domain.Webhook) error {
+	err := repository.Conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE webhooks
+		SET url = $2, secret = $3, events = $4, active = $5, updated_at = now()
+		WHERE id = $1
+		RETURNING updated_at
+	`, w.ID, w.URL, w.Secret, pq.Array(w.Events), w.Active).Scan(&w.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("webhook id=%d: %w", w.ID, domain.ErrWebhookNotFound)
+	}
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("webhook_id", w.ID).Msg("repository: Update webhook failed")
+		return fmt.Errorf("update webhook id=%d: %w", w.ID, repository.TranslateError(err))
+	}
+	return nil
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id int64) error {
+	res, err := repository.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("webhook_id", id).Msg("repository: Delete webhook failed")
+		return fmt.Errorf("delete webhook id=%d: %w", id, repository.TranslateError(err))
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("webhook id=%d: %w", id, domain.ErrWebhookNotFound)
+	}
+	return nil
+}
+
+func (r *webhookRepository) Enqueue(ctx context.Context, eventID, eventType string, payload []byte) (int64, error) {
+	res, err := repository.Conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3
+		FROM webhooks
+		WHERE active AND (cardinality(events) = 0 OR $2 = ANY(events))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING
+	`, eventID, eventType, string(payload))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("event_id", eventID).Msg("repository: Enqueue deliveries failed")
+		return 0, fmt.Errorf("enqueue event %s: %w", eventID, repository.TranslateError(err))
+	}
+	return res.RowsAffected()
+}
+
+func (r *webhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	// SKIP LOCKED позволяет нескольким экземплярам разбирать очередь параллельно.
+	// Доставки отключённого вебхука ждут в очереди, пока его не включат снова.
+	rows, err := repository.Conn(ctx, r.db).QueryContext(ctx, `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND w.active
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id AND w.active
+		RETURNING `+deliveryColumns+`, w.url, w.secret
+	`, limit, lease.Seconds())
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("repository: ClaimDue failed")
+		return nil, fmt.Errorf("claim due deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, d *domain.WebhookDelivery, a *domain.DeliveryAttempt) error {
+	err := repository.InTx(ctx, r.db, func(ctx context.Context) error {
+		tx := repository.Conn(ctx, r.db)
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+			VALUES ($1, $2, $3, $4, $5)
+		`, d.ID, a.Attempt, a.StatusCode, a.Error, a.Duration.Milliseconds()); err != nil {
+			return fmt.Errorf("insert attempt: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = $2, attempts = $3, next_attempt_at = $4,
+			    last_status_code = $5, last_error = $6, delivered_at = $7
+			WHERE id = $1
+		`, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("delivery_id", d.ID).Msg("repository: RecordAttempt failed")
+		return fmt.Errorf("record attempt for delivery id=%d: %w", d.ID, repository.TranslateError(err))
+	}
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID int64, status domain.DeliveryStatus, page domain.PageRequest) ([]*domain.WebhookDelivery, error) {
+	cur, scanDesc, reverse := repository.KeysetScan(true, page)
+
+	args := []interface{}{webhookID}
+	conds := []string{"d.webhook_id = $1"}
+	if status != "" {
+		args = append(args, status)
+		conds = append(conds, fmt.Sprintf("d.status = $%d", len(args)))
+	}
+
+	offset := page.Offset
+	if cur != nil {
+		args = append(args, cur.CreatedAt, cur.ID)
+		conds = append(conds, fmt.Sprintf("(d.created_at, d.id) %s ($%d, $%d)",
+			repository.KeysetOperator(scanDesc), len(args)-1, len(args)))
+		offset = 0
+	}
+
+	args = append(args, page.Limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %[1]s
+		FROM webhook_deliveries d
+		WHERE %[2]s
+		ORDER BY d.created_at %[3]s, d.id %[3]s
+		LIMIT $%[4]d OFFSET $%[5]d
+	`, deliveryColumns, strings.Join(conds, " AND "), repository.Direction(scanDesc), len(args)-1, len(args))
+
+	rows, err := repository.Query(ctx, r.db, r.strategy, query, args...)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("webhook_id", webhookID).Msg("repository: ListDeliveries failed")
+		return nil, fmt.Errorf("list deliveries for webhook id=%d: %w", webhookID, err)
+	}
+	defer rows.Close()
+
+	var out []*domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows, false)
+		if err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate deliveries: %w", err)
+	}
+	if reverse {
+		repository.Reverse(out)
+	}
+	return out, nil
+}
+
+func (r *webhookRepository) FindDelivery(ctx context.Context, webhookID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	row := repository.Conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries d
+		WHERE d.id = $1 AND d.webhook_id = $2
+	`, deliveryID, webhookID)
+	d, err := scanDelivery(row, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("delivery id=%d: %w", deliveryID, domain.ErrDeliveryNotFound)
+	}
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("delivery_id", deliveryID).Msg("repository: FindDelivery failed")
+		return nil, fmt.Errorf("find delivery id=%d: %w", deliveryID, err)
+	}
+	return d, nil
+}
+
+func (r *webhookRepository) ListAttempts(ctx context.Context, deliveryID int64) ([]*domain.DeliveryAttempt, error) {
+	rows, err := repository.Query(ctx, r.db, r.strategy, `
+		SELECT delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY id
+	`, deliveryID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("delivery_id", deliveryID).Msg("repository: ListAttempts failed")
+		return nil, fmt.Errorf("list attempts for delivery id=%d: %w", deliveryID, err)
+	}
+	defer rows.Close()
+
+	var out []*domain.DeliveryAttempt
+	for rows.Next() {
+		var (
+			a          domain.DeliveryAttempt
+			statusCode sql.NullInt64
+			errText    sql.NullString
+			durationMS int64
+		)
+		if err := rows.Scan(&a.DeliveryID, &a.Attempt, &statusCode, &errText, &durationMS, &a.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("scan attempt: %w", err)
+		}
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			a.StatusCode = &code
+		}
+		if errText.Valid {
+			a.Error = &errText.String
+		}
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+		out = append(out, &a)
+	}
+	return out, rows.Err()
+}
+
+func (r *webhookRepository) Replay(ctx context.Context, webhookID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	row := repository.Conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET status = 'pending', attempts_before_replay = attempts, next_attempt_at = now(), delivered_at = NULL
+		WHERE d.id = $1 AND d.webhook_id = $2
+		RETURNING `+deliveryColumns, deliveryID, webhookID)
+	d, err := scanDelivery(row, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("delivery id=%d: %w", deliveryID, domain.ErrDeliveryNotFound)
+	}
+	if err != nil {
+		zlog.Logger.Error().Err(err).Int64("delivery_id", deliveryID).Msg("repository: Replay delivery failed")
+		return nil, fmt.Errorf("replay delivery id=%d: %w", deliveryID, repository.TranslateError(err))
+	}
+	return d, nil
+}
+
+func scanWebhook(row repository.RowScanner) (*domain.Webhook, error) {
+	var w domain.Webhook
+	if err := row.Scan(&w.ID, &w.URL, &w.Secret, pq.Array(&w.Events), &w.Active, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// scanDelivery читает deliveryColumns; withTarget — за ними следуют url и secret вебхука
+func scanDelivery(row repository.RowScanner, withTarget bool) (*domain.WebhookDelivery, error) {
+	var (
+		d           domain.WebhookDelivery
+		statusCode  sql.NullInt64
+		lastError   sql.NullString
+		deliveredAt sql.NullTime
+	)
+	dest := []interface{}{&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.AttemptsBeforeReplay, &d.NextAttemptAt, &statusCode, &lastError, &d.CreatedAt, &deliveredAt}
+	if withTarget {
+		dest = append(dest, &d.URL, &d.Secret)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		d.LastStatusCode = &code
+	}
+	if lastError.Valid {
+		d.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return &d, nil
+}
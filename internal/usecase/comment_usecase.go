@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/wb-go/wbf/zlog"
 	"github.com/yokitheyo/CommentTree/internal/infrastructure/search"
+	"github.com/yokitheyo/CommentTree/internal/pkg/diff"
+	"github.com/yokitheyo/CommentTree/internal/pkg/markdown"
 
 	"github.com/yokitheyo/CommentTree/internal/domain"
 )
@@ -14,27 +19,192 @@ import (
 type CommentUsecase struct {
 	repo   domain.CommentRepository
 	search search.FullTextSearcher
+	tx     domain.Transactor
+
+	premoderation       bool
+	premoderatedThreads map[string]struct{}
+	spam                *SpamFilter
+	requireAuth         bool
+	managementWindow    time.Duration
+	notifier            *notifier
+	stream              domain.EventStream
+	validator           *Validator
+	permalinkDepth      int
+	maxPermalinkDepth   int
+}
+
+// Option настраивает необязательное поведение CommentUsecase
+type Option func(*CommentUsecase)
+
+// WithPremoderation включает премодерацию для всех обсуждений (all) или только для перечисленных.
+func WithPremoderation(all bool, threads []string) Option {
+	return func(u *CommentUsecase) {
+		u.premoderation = all
+		u.premoderatedThreads = make(map[string]struct{}, len(threads))
+		for _, t := range threads {
+			u.premoderatedThreads[t] = struct{}{}
+		}
+	}
+}
+
+// WithSpamFilter прогоняет каждый новый комментарий через цепочку спам-классификаторов.
+func WithSpamFilter(f *SpamFilter) Option {
+	return func(u *CommentUsecase) {
+		u.spam = f
+	}
+}
+
+// WithRequireAuth запрещает анонимную запись: автор и редактор берутся только
+// из токена. Голосование требует токена всегда.
+func WithRequireAuth(required bool) Option {
+	return func(u *CommentUsecase) {
+		u.requireAuth = required
+	}
+}
+
+// WithManagementTokens выдаёт анонимным авторам токен, которым в течение window
+// можно править и удалять свой комментарий; window <= 0 отключает токены.
+func WithManagementTokens(window time.Duration) Option {
+	return func(u *CommentUsecase) {
+		u.managementWindow = window
+	}
+}
+
+// WithPermalinkDepth задаёт, сколько уровней ответов GetComment отдаёт по умолчанию
+// и сколько самое большее; max <= 0 снимает ограничение.
+func WithPermalinkDepth(def, max int) Option {
+	return func(u *CommentUsecase) {
+		u.permalinkDepth = def
+		u.maxPermalinkDepth = max
+	}
+}
+
+// WithNotifications сохраняет упоминания и уведомляет упомянутых и авторов,
+// которым ответили.
+func WithNotifications(notifications domain.NotificationRepository) Option {
+	return func(u *CommentUsecase) {
+		u.notifier = &notifier{comments: u.repo, notifications: notifications}
+	}
+}
+
+// WithTransactor выполняет проверку прав и изменение комментария в одной транзакции.
+func WithTransactor(tx domain.Transactor) Option {
+	return func(u *CommentUsecase) {
+		u.tx = orNoTx(tx)
+	}
+}
+
+// WithValidator задаёт ограничения на текст, имена и вложенность комментариев.
+func WithValidator(v *Validator) Option {
+	return func(u *CommentUsecase) {
+		u.validator = v
+	}
+}
+
+// WithEventStream включает живую ленту комментариев для читателей.
+func WithEventStream(stream domain.EventStream) Option {
+	return func(u *CommentUsecase) {
+		u.stream = stream
+	}
+}
+
+func NewCommentUsecase(repo domain.CommentRepository, search search.FullTextSearcher, opts ...Option) *CommentUsecase {
+	u := &CommentUsecase{
+		repo:      repo,
+		search:    search,
+		tx:        noTx{},
+		validator: &Validator{},
+
+		permalinkDepth: 3,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
-func NewCommentUsecase(repo domain.CommentRepository, search search.FullTextSearcher) *CommentUsecase {
-	return &CommentUsecase{
-		repo:   repo,
-		search: search,
+func (u *CommentUsecase) isPremoderated(threadKey string) bool {
+	if u.premoderation {
+		return true
 	}
+	_, ok := u.premoderatedThreads[threadKey]
+	return ok
 }
 
-func (u *CommentUsecase) CreateComment(ctx context.Context, parentID *int64, author, content string) (*domain.Comment, error) {
-	if author == "" {
-		return nil, errors.New("author required")
+// identity возвращает имя пишущего: из токена, если он есть, иначе указанное клиентом
+func (u *CommentUsecase) identity(ctx context.Context, claimed string) (string, error) {
+	if p, ok := domain.PrincipalFromContext(ctx); ok {
+		return p.Name, nil
+	}
+	if u.requireAuth {
+		return "", domain.ErrUnauthorized
+	}
+	return claimed, nil
+}
+
+func (u *CommentUsecase) CreateComment(ctx context.Context, parentID *int64, threadKey, author, content string) (*domain.Comment, error) {
+	author, err := u.identity(ctx, author)
+	if err != nil {
+		return nil, err
+	}
+	verr := &domain.ValidationError{}
+	if author = u.validator.Name(verr, "author", author); author == "" {
+		verr.Add("author", "required")
+	}
+	content = u.validator.Content(verr, content)
+	threadKey = u.validator.ThreadKey(verr, threadKey)
+	if parentID == nil && threadKey == "" {
+		verr.Add("thread_key", "required for root comments")
 	}
-	if content == "" {
-		return nil, errors.New("content required")
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		// Ответ всегда живёт в обсуждении своего родителя
+		parent, err := u.repo.FindByID(ctx, *parentID)
+		if errors.Is(err, domain.ErrCommentNotFound) {
+			return nil, fmt.Errorf("parent id=%d: %w", *parentID, domain.ErrParentNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("find parent id=%d: %w", *parentID, err)
+		}
+		if !domain.ViewerFromContext(ctx).CanSee(parent) {
+			return nil, fmt.Errorf("parent id=%d: %w", *parentID, domain.ErrParentNotFound)
+		}
+		if threadKey != "" && threadKey != parent.ThreadKey {
+			return nil, fmt.Errorf("parent id=%d: %w", *parentID, domain.ErrThreadKeyMismatch)
+		}
+		if err := u.checkReplyAllowed(ctx, parent); err != nil {
+			return nil, fmt.Errorf("parent id=%d: %w", *parentID, err)
+		}
+		threadKey = parent.ThreadKey
 	}
 
 	c := &domain.Comment{
-		ParentID: parentID,
-		Author:   author,
-		Content:  content,
+		ParentID:    parentID,
+		ThreadKey:   threadKey,
+		Author:      author,
+		Content:     content,
+		ContentHTML: markdown.Render(content),
+		Status:      domain.StatusApproved,
+	}
+	if u.isPremoderated(threadKey) {
+		c.Status = domain.StatusPending
+	}
+	if u.spam != nil {
+		u.spam.apply(ctx, c)
+	}
+
+	var token string
+	p, authenticated := domain.PrincipalFromContext(ctx)
+	if authenticated {
+		c.AuthorSubject = p.Subject
+	} else if u.managementWindow > 0 {
+		token, c.ManagementTokenHash, err = newManagementToken()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if err := u.repo.Save(ctx, c); err != nil {
@@ -42,65 +212,540 @@ func (u *CommentUsecase) CreateComment(ctx context.Context, parentID *int64, aut
 		return nil, fmt.Errorf("save comment: %w", err)
 	}
 
-	zlog.Logger.Info().Msgf("comment created id=%d parent=%v", c.ID, c.ParentID)
+	c.ManagementToken = token
+	if u.notifier != nil {
+		u.notifier.commentCreated(ctx, c)
+	}
+
+	zlog.Logger.Info().Msgf("comment created id=%d parent=%v thread=%s status=%s", c.ID, c.ParentID, c.ThreadKey, c.Status)
 	return c, nil
 }
 
-func (u *CommentUsecase) GetThread(ctx context.Context, parentID *int64, limit, offset int, sort string) ([]*domain.Comment, error) {
-	comments, err := u.repo.FindChildren(ctx, parentID, limit, offset, sort)
+func (u *CommentUsecase) GetThread(ctx context.Context, q domain.ThreadQuery) (*domain.CommentPage, error) {
+	// Корневые комментарии всех обсуждений сразу не выдаются: такой запрос обходил бы всю таблицу
+	if q.ParentID == nil && q.ThreadKey == "" {
+		return nil, domain.Invalid("thread", "is required to list top-level comments")
+	}
+	q.Viewer = domain.ViewerFromContext(ctx)
+	fetch := q
+	fetch.Page = fetchOneMore(q.Page)
+
+	comments, err := u.repo.FindChildren(ctx, fetch)
 	if err != nil {
 		zlog.Logger.Error().Err(err).Msg("usecase: FindChildren failed")
-		return nil, fmt.Errorf("find children for parent_id=%v: %w", parentID, err)
+		return nil, fmt.Errorf("find children for parent_id=%v: %w", q.ParentID, err)
 	}
 
-	zlog.Logger.Info().Msgf("GetThread found %d comments for parent_id=%v", len(comments), parentID)
+	comments, next, prev := paginate(comments, q.Page, commentCursor(q.Sort))
+	page := &domain.CommentPage{Items: comments, Next: next, Prev: prev}
 
+	zlog.Logger.Info().Msgf("GetThread found %d comments for parent_id=%v", len(comments), q.ParentID)
+
+	if len(comments) == 0 {
+		return page, nil
+	}
+
+	rootIDs := make([]int64, 0, len(comments))
 	for _, comment := range comments {
-		if err := u.loadAllChildren(ctx, comment); err != nil {
-			zlog.Logger.Error().Err(err).Msgf("failed to load children for comment %d", comment.ID)
+		rootIDs = append(rootIDs, comment.ID)
+	}
+
+	descendants, err := u.repo.FindSubtree(ctx, rootIDs, 0)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("usecase: FindSubtree failed")
+		return nil, fmt.Errorf("find subtree for parent_id=%v: %w", q.ParentID, err)
+	}
+
+	buildTree(comments, descendants)
+	if domain.IsScoreSort(q.Sort) {
+		sortSiblings(comments, q.Sort)
+	}
+	zlog.Logger.Debug().Msgf("loaded %d descendants for %d comments", len(descendants), len(comments))
+
+	page.Items = pruneDeleted(pruneInvisible(comments, q.Viewer), q.HideDeleted)
+
+	return page, nil
+}
+
+// checkReplyAllowed отклоняет ответ на удалённый комментарий, в закрытую ветку
+// (модераторам обсуждения можно) и глубже допустимой вложенности
+func (u *CommentUsecase) checkReplyAllowed(ctx context.Context, parent *domain.Comment) error {
+	if parent.Deleted {
+		return domain.ErrParentDeleted
+	}
+
+	ancestors, err := u.repo.FindAncestors(ctx, parent.ID)
+	if err != nil {
+		return fmt.Errorf("find ancestors: %w", err)
+	}
+
+	if !domain.ViewerFromContext(ctx).Moderates(parent.ThreadKey) {
+		locked := parent.Locked
+		for _, a := range ancestors {
+			locked = locked || a.Locked
+		}
+		if locked {
+			return domain.ErrParentLocked
+		}
+	}
+
+	return u.validator.Depth(len(ancestors))
+}
+
+// GetComment отдаёт комментарий для постоянной ссылки. Удалённые комментарии цепочки
+// показываются заглушками; если читатель не видит сам комментарий или кого-то из предков,
+// комментарий считается ненайденным — как и в ленте, где такая ветка скрыта целиком.
+func (u *CommentUsecase) GetComment(ctx context.Context, id int64, depth int) (*domain.CommentContext, error) {
+	if id <= 0 {
+		return nil, domain.Invalid("id", "must be positive")
+	}
+	if depth < 0 {
+		depth = u.permalinkDepth
+	}
+	if u.maxPermalinkDepth > 0 {
+		depth = min(depth, u.maxPermalinkDepth)
+	}
+	viewer := domain.ViewerFromContext(ctx)
+
+	c, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find comment id=%d: %w", id, err)
+	}
+	if !viewer.CanSee(c) {
+		return nil, fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
+	}
+
+	ancestors, err := u.repo.FindAncestors(ctx, id)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msgf("usecase: FindAncestors failed id=%d", id)
+		return nil, fmt.Errorf("find ancestors id=%d: %w", id, err)
+	}
+	for _, a := range ancestors {
+		if !viewer.CanSee(a) {
+			return nil, fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
 		}
+		if a.Deleted {
+			a.Tombstone()
+		}
+	}
+
+	// FindSubtree с maxDepth <= 0 отдаёт всё поддерево, поэтому depth 0 обрабатывается отдельно
+	if depth > 0 {
+		descendants, err := u.repo.FindSubtree(ctx, []int64{id}, depth)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msgf("usecase: FindSubtree failed id=%d", id)
+			return nil, fmt.Errorf("find subtree id=%d: %w", id, err)
+		}
+		buildTree([]*domain.Comment{c}, descendants)
+		c.Children = pruneDeleted(pruneInvisible(c.Children, viewer), false)
+	}
+	if c.Deleted {
+		c.Tombstone()
 	}
 
-	return comments, nil
+	zlog.Logger.Debug().Msgf("GetComment id=%d ancestors=%d depth=%d", id, len(ancestors), depth)
+	return &domain.CommentContext{Comment: c, Ancestors: ancestors, Depth: depth}, nil
 }
 
-func (u *CommentUsecase) loadAllChildren(ctx context.Context, comment *domain.Comment) error {
-	children, err := u.repo.FindChildren(ctx, &comment.ID, 1000, 0, "asc")
+// buildTree раскладывает плоский список потомков по Children их родителей.
+// descendants должны быть упорядочены так, как дети должны идти внутри родителя.
+func buildTree(roots, descendants []*domain.Comment) {
+	byID := make(map[int64]*domain.Comment, len(roots)+len(descendants))
+	for _, c := range roots {
+		byID[c.ID] = c
+	}
+	for _, c := range descendants {
+		byID[c.ID] = c
+	}
+
+	for _, c := range descendants {
+		if c.ParentID == nil {
+			continue
+		}
+		if parent, ok := byID[*c.ParentID]; ok {
+			parent.Children = append(parent.Children, c)
+		}
+	}
+}
+
+func (u *CommentUsecase) EditComment(ctx context.Context, id int64, editor, content string) (*domain.Comment, error) {
+	if id <= 0 {
+		return nil, domain.Invalid("id", "must be positive")
+	}
+	editor, err := u.identity(ctx, editor)
 	if err != nil {
-		return fmt.Errorf("load children for comment %d: %w", comment.ID, err)
+		return nil, err
+	}
+	verr := &domain.ValidationError{}
+	editor = u.validator.Name(verr, "editor", editor)
+	content = u.validator.Content(verr, content)
+	if err := verr.Err(); err != nil {
+		return nil, err
 	}
 
-	comment.Children = children
-	zlog.Logger.Debug().Msgf("loaded %d children for comment %d", len(children), comment.ID)
+	// Правка и запись ревизии либо проходят вместе с проверкой прав, либо не проходят вовсе
+	var c *domain.Comment
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := u.authorize(ctx, id, ActionEdit)
+		if err != nil {
+			return err
+		}
+		// Анонимный автор с токеном управления может не называться заново
+		if editor == "" {
+			editor = current.Author
+		}
 
-	for _, child := range children {
-		if err := u.loadAllChildren(ctx, child); err != nil {
-			zlog.Logger.Error().Err(err).Msgf("failed to load children for comment %d", child.ID)
+		c, err = u.repo.Update(ctx, u.remoderate(ctx, current, content), editor)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msgf("usecase: Update failed id=%d", id)
+			return fmt.Errorf("edit comment id=%d: %w", id, err)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	zlog.Logger.Info().Msgf("comment edited id=%d editor=%s", id, editor)
+	return c, nil
+}
+
+// remoderate готовит правку current с новым текстом и заново проверяет её, как при создании:
+// одобренный комментарий в премодерируемом обсуждении или с подозрением на спам снова
+// уходит в очередь. Отклонённый остаётся отклонённым.
+func (u *CommentUsecase) remoderate(ctx context.Context, current *domain.Comment, content string) *domain.Comment {
+	edit := *current
+	edit.Content = content
+	edit.ContentHTML = markdown.Render(content)
+	if edit.Status == domain.StatusRejected {
+		return &edit
+	}
+
+	if edit.Status == domain.StatusApproved && u.isPremoderated(edit.ThreadKey) {
+		edit.Status = domain.StatusPending
+		edit.ModerationReason, edit.ModeratedBy, edit.ModeratedAt = nil, nil, nil
+	}
+	if u.spam != nil {
+		u.spam.apply(ctx, &edit)
+	}
+	if edit.Status != current.Status {
+		zlog.Logger.Info().Msgf("edited comment id=%d moved from %s to %s", edit.ID, current.Status, edit.Status)
+	}
+	return &edit
+}
+
+// ListRevisions возвращает историю правок; последней идёт текущая версия комментария
+func (u *CommentUsecase) ListRevisions(ctx context.Context, id int64) ([]*domain.Revision, error) {
+	c, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find comment id=%d: %w", id, err)
+	}
+	// Историю удалённого комментария видят только модераторы его обсуждения
+	viewer := domain.ViewerFromContext(ctx)
+	if c.Deleted && !viewer.Moderates(c.ThreadKey) {
+		return nil, fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentDeleted)
+	}
+	if !viewer.CanSee(c) {
+		return nil, fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
+	}
+
+	revisions, err := u.repo.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list revisions id=%d: %w", id, err)
+	}
+
+	current := &domain.Revision{
+		CommentID: c.ID,
+		Number:    len(revisions) + 1,
+		Content:   c.Content,
+		Editor:    c.Author,
+		CreatedAt: c.CreatedAt,
+		Current:   true,
+	}
+	if c.EditedBy != nil {
+		current.Editor = *c.EditedBy
+	}
+	if c.EditedAt != nil {
+		current.CreatedAt = *c.EditedAt
+	}
+
+	return append(revisions, current), nil
+}
+
+func (u *CommentUsecase) DiffRevisions(ctx context.Context, id int64, from, to int) (*domain.RevisionDiff, error) {
+	revisions, err := u.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	find := func(n int) (*domain.Revision, error) {
+		if n < 1 || n > len(revisions) {
+			return nil, fmt.Errorf("revision %d of comment id=%d: %w", n, id, domain.ErrRevisionNotFound)
+		}
+		return revisions[n-1], nil
+	}
+
+	a, err := find(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := find(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RevisionDiff{From: from, To: to, Chunks: diff.Words(a.Content, b.Content)}, nil
+}
+
+// sortSiblings упорядочивает детей каждого узла по голосам; корни уже отсортированы БД
+func sortSiblings(roots []*domain.Comment, sort string) {
+	for _, c := range roots {
+		if len(c.Children) == 0 {
+			continue
+		}
+		slices.SortStableFunc(c.Children, func(a, b *domain.Comment) int {
+			ka, kb := domain.SortKey(sort, a), domain.SortKey(sort, b)
+			switch {
+			case ka > kb:
+				return -1
+			case ka < kb:
+				return 1
+			}
+			return b.CreatedAt.Compare(a.CreatedAt)
+		})
+		sortSiblings(c.Children, sort)
+	}
+}
+
+// pruneInvisible убирает ветки, корень которых читателю не виден (например, ожидает модерации)
+func pruneInvisible(comments []*domain.Comment, v domain.Viewer) []*domain.Comment {
+	out := comments[:0]
+	for _, c := range comments {
+		if !v.CanSee(c) {
+			continue
+		}
+		c.Children = pruneInvisible(c.Children, v)
+		out = append(out, c)
+	}
+	return out
+}
+
+// pruneDeleted убирает удалённые комментарии без живых потомков, а остальные удалённые
+// превращает в заглушки. С hide удалённые ветки убираются целиком.
+func pruneDeleted(comments []*domain.Comment, hide bool) []*domain.Comment {
+	out := comments[:0]
+	for _, c := range comments {
+		if c.Deleted && hide {
+			continue
+		}
+		c.Children = pruneDeleted(c.Children, hide)
+		if c.Deleted {
+			if len(c.Children) == 0 {
+				continue
+			}
+			c.Tombstone()
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// VoteComment сохраняет голос аутентифицированного пользователя. Голосующий — субъект
+// токена: имя из тела запроса аноним мог бы менять при каждом голосе.
+// Комментарий, который читатель не видит, для голосования не существует.
+func (u *CommentUsecase) VoteComment(ctx context.Context, id int64, value int) (*domain.Comment, error) {
+	if id <= 0 {
+		return nil, domain.Invalid("id", "must be positive")
+	}
+	if value < -1 || value > 1 {
+		return nil, domain.ErrInvalidVote
+	}
+	p, ok := domain.PrincipalFromContext(ctx)
+	if !ok || p.Subject == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	var c *domain.Comment
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := u.repo.FindByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("find comment id=%d: %w", id, err)
+		}
+		if !domain.ViewerFromContext(ctx).CanSee(current) {
+			return fmt.Errorf("comment id=%d: %w", id, domain.ErrCommentNotFound)
+		}
+
+		c, err = u.repo.Vote(ctx, id, p.Subject, value)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msgf("usecase: Vote failed id=%d", id)
+			return fmt.Errorf("vote comment id=%d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	zlog.Logger.Info().Msgf("comment voted id=%d value=%d score=%d", id, value, c.Score)
+	return c, nil
+}
+
+func (u *CommentUsecase) DeleteThread(ctx context.Context, id int64, cascade bool) error {
+	if id <= 0 {
+		return domain.Invalid("id", "must be positive")
+	}
+	action := ActionDelete
+	if cascade {
+		action = ActionDeleteSubtree
+	}
+
+	var affected int64
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := u.authorize(ctx, id, action); err != nil {
+			return err
+		}
+
+		var err error
+		affected, err = u.repo.Delete(ctx, id, cascade)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msgf("usecase: Delete failed id=%d", id)
+			return fmt.Errorf("delete comment id=%d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	zlog.Logger.Info().Msgf("comment deleted id=%d cascade=%t affected=%d", id, cascade, affected)
 	return nil
 }
 
-func (u *CommentUsecase) DeleteThread(ctx context.Context, id int64) error {
+func (u *CommentUsecase) RestoreThread(ctx context.Context, id int64, cascade bool) error {
 	if id <= 0 {
-		return errors.New("invalid id")
+		return domain.Invalid("id", "must be positive")
 	}
-	if err := u.repo.Delete(ctx, id); err != nil {
-		zlog.Logger.Error().Err(err).Msgf("usecase: Delete failed id=%d", id)
-		return fmt.Errorf("delete comment id=%d: %w", id, err)
+
+	var affected int64
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := u.authorize(ctx, id, ActionRestore); err != nil {
+			return err
+		}
+
+		var err error
+		affected, err = u.repo.Restore(ctx, id, cascade)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msgf("usecase: Restore failed id=%d", id)
+			return fmt.Errorf("restore comment id=%d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	zlog.Logger.Info().Msgf("comment deleted id=%d", id)
+	zlog.Logger.Info().Msgf("comment restored id=%d cascade=%t affected=%d", id, cascade, affected)
 	return nil
 }
 
-func (u *CommentUsecase) SearchComment(ctx context.Context, q string, limit, offset int) ([]*domain.Comment, error) {
-	if q == "" {
-		return nil, errors.New("empty query")
+// LockComment закрывает ветку комментария для новых ответов (locked=false снова открывает её);
+// это доступно только модераторам обсуждения
+func (u *CommentUsecase) LockComment(ctx context.Context, id int64, locked bool) (*domain.Comment, error) {
+	if id <= 0 {
+		return nil, domain.Invalid("id", "must be positive")
 	}
-	comments, err := u.search.SearchComments(ctx, q, limit, offset)
+
+	var c *domain.Comment
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := u.authorize(ctx, id, ActionLock); err != nil {
+			return err
+		}
+
+		var err error
+		c, err = u.repo.SetLocked(ctx, id, locked)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msgf("usecase: SetLocked failed id=%d", id)
+			return fmt.Errorf("lock comment id=%d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	zlog.Logger.Info().Msgf("comment lock changed id=%d locked=%t", id, locked)
+	return c, nil
+}
+
+// authorize загружает комментарий и проверяет, что читатель может выполнить над ним действие
+func (u *CommentUsecase) authorize(ctx context.Context, id int64, action Action) (*domain.Comment, error) {
+	c, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find comment id=%d: %w", id, err)
+	}
+	viewer := domain.ViewerFromContext(ctx)
+	if err := Authorize(viewer, action, c); err != nil {
+		if viewer.ManagementToken == "" {
+			zlog.Logger.Warn().Err(err).Int64("comment_id", id).Msg("usecase: action denied")
+			return nil, err
+		}
+		if err := u.checkManagementToken(ctx, c, action, viewer.ManagementToken); err != nil {
+			zlog.Logger.Warn().Err(err).Int64("comment_id", id).Msg("usecase: management token rejected")
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (u *CommentUsecase) ListThreads(ctx context.Context, limit, offset int) ([]*domain.ThreadSummary, error) {
+	threads, err := u.repo.ListThreads(ctx, limit, offset)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("usecase: ListThreads failed")
+		return nil, fmt.Errorf("list threads: %w", err)
+	}
+	return threads, nil
+}
+
+func (u *CommentUsecase) SearchComment(ctx context.Context, q domain.SearchQuery) (*domain.SearchPage, error) {
+	if q.Text == "" {
+		return nil, domain.Invalid("query", "required")
+	}
+
+	q.Viewer = domain.ViewerFromContext(ctx)
+	fetch := q
+	fetch.Page = fetchOneMore(q.Page)
+
+	hits, err := u.search.SearchComments(ctx, fetch)
 	if err != nil {
 		return nil, fmt.Errorf("search comments: %w", err)
 	}
-	return comments, nil
+
+	hits, next, prev := paginate(hits, q.Page, searchHitCursor)
+	return &domain.SearchPage{Hits: hits, Next: next, Prev: prev}, nil
+}
+
+// StreamComments подписывает читателя на события обсуждения. Читатель получает
+// только то, что мог бы увидеть в ленте; канал закрывается вместе с ctx.
+func (u *CommentUsecase) StreamComments(ctx context.Context, q domain.StreamQuery) (<-chan *domain.CommentEvent, error) {
+	if u.stream == nil {
+		return nil, domain.ErrStreamUnavailable
+	}
+	q.ThreadKey = strings.TrimSpace(q.ThreadKey)
+	viewer := domain.ViewerFromContext(ctx)
+
+	source := u.stream.Subscribe(ctx, q.LastEventID)
+	out := make(chan *domain.CommentEvent)
+	go func() {
+		defer close(out)
+		for e := range source {
+			if e.Comment != nil && (!q.Matches(e) || !viewer.CanSee(e.Comment)) {
+				continue
+			}
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	zlog.Logger.Debug().Msgf("stream subscribed thread=%q parent=%v last_event_id=%d", q.ThreadKey, q.ParentID, q.LastEventID)
+	return out, nil
 }
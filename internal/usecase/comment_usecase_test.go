@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+func TestRemoderate(t *testing.T) {
+	// Одно запрещённое слово — в очередь, два — отклонить
+	blocklist, err := NewBlocklistClassifier([]string{"casino", "bonus"}, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := NewCommentUsecase(nil, nil,
+		WithPremoderation(false, []string{"premoderated"}),
+		WithSpamFilter(NewSpamFilter(1, 2, blocklist)),
+	)
+
+	moderator := "mod"
+	approvedAt := time.Unix(1_700_000_000, 0)
+	comment := func(thread string, status domain.ModerationStatus) *domain.Comment {
+		c := &domain.Comment{ID: 1, ThreadKey: thread, Author: "alice", Content: "hello", Status: status}
+		if status != domain.StatusPending {
+			c.ModeratedBy, c.ModeratedAt = &moderator, &approvedAt
+		}
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		current     *domain.Comment
+		content     string
+		want        domain.ModerationStatus
+		wantDecided bool // остаётся ли решение модератора
+	}{
+		{"clean edit stays approved", comment("t", domain.StatusApproved), "hello again", domain.StatusApproved, true},
+		{"spam edit goes back to pending", comment("t", domain.StatusApproved), "visit my casino", domain.StatusPending, false},
+		{"heavy spam edit is rejected", comment("t", domain.StatusApproved), "casino bonus", domain.StatusRejected, true},
+		{"premoderated thread goes back to pending", comment("premoderated", domain.StatusApproved), "hello again", domain.StatusPending, false},
+		{"pending stays pending", comment("t", domain.StatusPending), "hello again", domain.StatusPending, false},
+		{"rejected stays rejected", comment("t", domain.StatusRejected), "hello again", domain.StatusRejected, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit := u.remoderate(context.Background(), tt.current, tt.content)
+			if edit.Content != tt.content || edit.ContentHTML == "" {
+				t.Fatalf("content = %q, html = %q", edit.Content, edit.ContentHTML)
+			}
+			if edit.Status != tt.want {
+				t.Fatalf("status = %s, want %s", edit.Status, tt.want)
+			}
+			if decided := edit.ModeratedBy != nil; decided != tt.wantDecided {
+				t.Fatalf("moderated_by set = %v, want %v", decided, tt.wantDecided)
+			}
+			if tt.current.Content != "hello" {
+				t.Fatalf("current comment modified: %q", tt.current.Content)
+			}
+		})
+	}
+}
+
+// fakeRepo — хранилище комментариев в памяти; реализует только то, что нужно тестам
+type fakeRepo struct {
+	domain.CommentRepository
+	comments map[int64]*domain.Comment
+	// votes — голоса по комментарию и голосующему
+	votes map[int64]map[string]int
+	// queries — запросы к FindChildren
+	queries []domain.ThreadQuery
+}
+
+func newFakeRepo(comments ...*domain.Comment) *fakeRepo {
+	r := &fakeRepo{comments: make(map[int64]*domain.Comment), votes: make(map[int64]map[string]int)}
+	for _, c := range comments {
+		r.comments[c.ID] = c
+	}
+	return r
+}
+
+func (r *fakeRepo) FindByID(_ context.Context, id int64) (*domain.Comment, error) {
+	c, ok := r.comments[id]
+	if !ok {
+		return nil, domain.ErrCommentNotFound
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (r *fakeRepo) Vote(_ context.Context, id int64, voter string, value int) (*domain.Comment, error) {
+	if r.votes[id] == nil {
+		r.votes[id] = make(map[string]int)
+	}
+	r.votes[id][voter] = value
+	c := *r.comments[id]
+	for _, v := range r.votes[id] {
+		c.Score += v
+	}
+	return &c, nil
+}
+
+// FindChildren запоминает запрос и возвращает пустую страницу
+func (r *fakeRepo) FindChildren(_ context.Context, q domain.ThreadQuery) ([]*domain.Comment, error) {
+	r.queries = append(r.queries, q)
+	return nil, nil
+}
+
+func TestGetThreadScope(t *testing.T) {
+	parent := int64(7)
+	tests := []struct {
+		name    string
+		q       domain.ThreadQuery
+		wantErr error
+	}{
+		{"root of one thread", domain.ThreadQuery{ThreadKey: "post-1"}, nil},
+		{"replies of a comment", domain.ThreadQuery{ParentID: &parent}, nil},
+		{"replies within a thread", domain.ThreadQuery{ParentID: &parent, ThreadKey: "post-1"}, nil},
+		{"root of every thread", domain.ThreadQuery{}, domain.ErrValidation},
+		{"root of every thread without stubs", domain.ThreadQuery{HideDeleted: true}, domain.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			tt.q.Page = domain.PageRequest{Limit: 10}
+			_, err := NewCommentUsecase(repo, nil).GetThread(context.Background(), tt.q)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetThread() error = %v, want %v", err, tt.wantErr)
+			}
+			if queried := len(repo.queries) > 0; queried != (tt.wantErr == nil) {
+				t.Fatalf("repository queried = %v", queried)
+			}
+		})
+	}
+}
+
+func TestVoteComment(t *testing.T) {
+	alice := &domain.Principal{Subject: "sub-alice", Name: "alice", Role: domain.RoleUser}
+	// Другой аккаунт с тем же отображаемым именем голосует отдельно
+	aliceTwin := &domain.Principal{Subject: "sub-alice-2", Name: "alice", Role: domain.RoleUser}
+	mod := &domain.Principal{Subject: "sub-mod", Name: "mod", Role: domain.RoleModerator, Threads: []string{"t"}}
+
+	viewer := func(p *domain.Principal) domain.Viewer {
+		if p == nil {
+			return domain.Viewer{Role: domain.RoleAnonymous}
+		}
+		return domain.Viewer{Name: p.Name, Subject: p.Subject, Role: p.Role, Threads: p.Threads}
+	}
+
+	tests := []struct {
+		name      string
+		principal *domain.Principal
+		commentID int64
+		value     int
+		wantErr   error
+		wantVoter string
+	}{
+		{"anonymous cannot vote", nil, 1, 1, domain.ErrUnauthorized, ""},
+		{"token without subject", &domain.Principal{Name: "bob", Role: domain.RoleUser}, 1, 1, domain.ErrUnauthorized, ""},
+		{"user votes approved", alice, 1, 1, nil, "sub-alice"},
+		{"same name, other account", aliceTwin, 1, -1, nil, "sub-alice-2"},
+		{"invalid value", alice, 1, 2, domain.ErrValidation, ""},
+		{"pending of another author is hidden", alice, 2, 1, domain.ErrNotFound, ""},
+		{"rejected is hidden", alice, 3, 1, domain.ErrNotFound, ""},
+		{"moderator sees rejected", mod, 3, 1, nil, "sub-mod"},
+		{"missing comment", alice, 99, 1, domain.ErrNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(
+				&domain.Comment{ID: 1, ThreadKey: "t", Author: "bob", Status: domain.StatusApproved},
+				&domain.Comment{ID: 2, ThreadKey: "t", Author: "bob", Status: domain.StatusPending},
+				&domain.Comment{ID: 3, ThreadKey: "t", Author: "bob", Status: domain.StatusRejected},
+			)
+			u := NewCommentUsecase(repo, nil)
+
+			ctx := domain.WithViewer(context.Background(), viewer(tt.principal))
+			if tt.principal != nil {
+				ctx = domain.WithPrincipal(ctx, tt.principal)
+			}
+
+			_, err := u.VoteComment(ctx, tt.commentID, tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VoteComment() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.votes) != 0 {
+					t.Fatalf("vote stored despite error: %v", repo.votes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VoteComment() error = %v", err)
+			}
+			if got, ok := repo.votes[tt.commentID][tt.wantVoter]; !ok || got != tt.value {
+				t.Fatalf("votes = %v, want %s: %d", repo.votes, tt.wantVoter, tt.value)
+			}
+		})
+	}
+}
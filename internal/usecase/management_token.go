@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+const managementTokenBytes = 32
+
+// newManagementToken создаёт секретный токен управления комментарием и его хеш для хранения
+func newManagementToken() (string, []byte, error) {
+	buf := make([]byte, managementTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("generate management token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashManagementToken(token), nil
+}
+
+func hashManagementToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// checkManagementToken пускает анонимного автора править и удалять свой комментарий
+// по токену, выданному при создании, пока не истекло окно managementWindow.
+func (u *CommentUsecase) checkManagementToken(ctx context.Context, c *domain.Comment, action Action, token string) error {
+	if u.managementWindow <= 0 || (action != ActionEdit && action != ActionDelete) {
+		return forbidden(action, "management token cannot be used for this action")
+	}
+	if time.Since(c.CreatedAt) > u.managementWindow {
+		return forbidden(action, "management token expired")
+	}
+
+	hash, err := u.repo.FindManagementTokenHash(ctx, c.ID)
+	if err != nil {
+		return fmt.Errorf("find management token id=%d: %w", c.ID, err)
+	}
+	if len(hash) == 0 || subtle.ConstantTimeCompare(hash, hashManagementToken(token)) != 1 {
+		return forbidden(action, "invalid management token")
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// spamTrainingLimit ограничивает число последних решений модераторов, на которых обучается фильтр
+const spamTrainingLimit = 10000
+
+type ModerationUsecase struct {
+	repo     domain.CommentRepository
+	tx       domain.Transactor
+	spam     *SpamFilter
+	notifier *notifier
+}
+
+// NewModerationUsecase создаёт usecase модерации; tx, spam и notifications могут быть nil,
+// если транзакции не нужны, а спам-фильтр или уведомления выключены.
+func NewModerationUsecase(repo domain.CommentRepository, tx domain.Transactor, spam *SpamFilter, notifications domain.NotificationRepository) *ModerationUsecase {
+	u := &ModerationUsecase{repo: repo, tx: orNoTx(tx), spam: spam}
+	if notifications != nil {
+		u.notifier = &notifier{comments: repo, notifications: notifications}
+	}
+	return u
+}
+
+// ListQueue возвращает комментарии, ожидающие модерации, старые первыми.
+// Модератор отдельных обсуждений видит очередь только своих обсуждений.
+func (u *ModerationUsecase) ListQueue(ctx context.Context, threadKey string, page domain.PageRequest) (*domain.CommentPage, error) {
+	viewer := domain.ViewerFromContext(ctx)
+	if !viewer.IsModerator() {
+		return nil, forbidden(ActionModerate, "moderator role required")
+	}
+
+	var threads []string
+	switch {
+	case threadKey != "":
+		if !viewer.Moderates(threadKey) {
+			return nil, forbidden(ActionModerate, "moderator role required for thread "+threadKey)
+		}
+		threads = []string{threadKey}
+	case !viewer.ModeratesAll():
+		threads = viewer.Threads
+	}
+
+	comments, err := u.repo.FindByStatus(ctx, domain.StatusPending, threads, fetchOneMore(page))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("usecase: FindByStatus failed")
+		return nil, fmt.Errorf("list moderation queue: %w", err)
+	}
+
+	comments, next, prev := paginate(comments, page, commentCursor(domain.SortAsc))
+	return &domain.CommentPage{Items: comments, Next: next, Prev: prev}, nil
+}
+
+// Moderate одобряет или отклоняет сразу несколько комментариев с общей причиной
+func (u *ModerationUsecase) Moderate(ctx context.Context, ids []int64, decision domain.ModerationStatus, reason string) ([]*domain.Comment, error) {
+	viewer := domain.ViewerFromContext(ctx)
+	if !viewer.IsModerator() {
+		return nil, forbidden(ActionModerate, "moderator role required")
+	}
+	if len(ids) == 0 {
+		return nil, domain.Invalid("ids", "required")
+	}
+	if decision != domain.StatusApproved && decision != domain.StatusRejected {
+		return nil, domain.Invalid("decision", fmt.Sprintf("unknown moderation decision %q", decision))
+	}
+
+	moderator := viewer.Name
+	if moderator == "" {
+		moderator = "moderator"
+	}
+
+	// Пачка модерируется целиком: если хоть один комментарий недоступен, не меняется ни один
+	var comments []*domain.Comment
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if !viewer.ModeratesAll() {
+			for _, id := range ids {
+				c, err := u.repo.FindByID(ctx, id)
+				if err != nil {
+					return fmt.Errorf("find comment id=%d: %w", id, err)
+				}
+				if err := Authorize(viewer, ActionModerate, c); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		comments, err = u.repo.SetStatus(ctx, ids, decision, moderator, reason)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msg("usecase: SetStatus failed")
+			return fmt.Errorf("moderate comments: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Одобренные комментарии становятся видны всем — теперь можно уведомлять.
+	// Уведомления идут после фиксации: их ошибки только логируются и не откатывают решение.
+	if decision == domain.StatusApproved && u.notifier != nil {
+		for _, c := range comments {
+			u.notifier.commentPublished(ctx, c)
+		}
+	}
+
+	zlog.Logger.Info().Msgf("moderation: %d comments %s by %s", len(comments), decision, moderator)
+	return comments, nil
+}
+
+// TrainSpamFilter обучает спам-фильтр на комментариях, размеченных модераторами:
+// одобренные считаются нормальными, отклонённые — спамом. Возвращает число примеров.
+func (u *ModerationUsecase) TrainSpamFilter(ctx context.Context) (int, error) {
+	if u.spam == nil {
+		return 0, nil
+	}
+
+	comments, err := u.repo.FindModerated(ctx, domain.SpamFilterModerator, spamTrainingLimit)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("usecase: FindModerated failed")
+		return 0, fmt.Errorf("load moderated comments: %w", err)
+	}
+
+	samples := make([]SpamSample, 0, len(comments))
+	for _, c := range comments {
+		samples = append(samples, SpamSample{Text: c.Content, Spam: c.Status == domain.StatusRejected})
+	}
+
+	trained, err := u.spam.Fit(samples)
+	if err != nil {
+		return 0, fmt.Errorf("train spam filter: %w", err)
+	}
+
+	zlog.Logger.Info().Msgf("spam filter: %d classifiers trained on %d samples", trained, len(samples))
+	return len(samples), nil
+}
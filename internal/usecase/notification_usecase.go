@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+type NotificationUsecase struct {
+	repo domain.NotificationRepository
+}
+
+func NewNotificationUsecase(repo domain.NotificationRepository) *NotificationUsecase {
+	return &NotificationUsecase{repo: repo}
+}
+
+// ListNotifications возвращает уведомления текущего пользователя, новые первыми
+func (u *NotificationUsecase) ListNotifications(ctx context.Context, unreadOnly bool, page domain.PageRequest) (*domain.NotificationPage, error) {
+	recipient, err := notificationRecipient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := u.repo.List(ctx, recipient, unreadOnly, fetchOneMore(page))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("usecase: List notifications failed")
+		return nil, fmt.Errorf("list notifications: %w", err)
+	}
+	unread, err := u.repo.CountUnread(ctx, recipient)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("usecase: CountUnread failed")
+		return nil, fmt.Errorf("count unread notifications: %w", err)
+	}
+
+	items, next, prev := paginate(items, page, notificationCursor)
+	return &domain.NotificationPage{Items: items, UnreadCount: unread, Next: next, Prev: prev}, nil
+}
+
+// MarkNotificationsRead отмечает уведомления прочитанными; пустой ids — все сразу
+func (u *NotificationUsecase) MarkNotificationsRead(ctx context.Context, ids []int64) (int64, error) {
+	recipient, err := notificationRecipient(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := u.repo.MarkRead(ctx, recipient, ids)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("usecase: MarkRead failed")
+		return 0, fmt.Errorf("mark notifications read: %w", err)
+	}
+
+	zlog.Logger.Info().Msgf("notifications: %d marked read for %s", n, recipient)
+	return n, nil
+}
+
+// notificationRecipient — уведомления доступны только пользователю с известным именем
+func notificationRecipient(ctx context.Context) (string, error) {
+	v := domain.ViewerFromContext(ctx)
+	if v.Name == "" || v.Role == "" || v.Role == domain.RoleAnonymous {
+		return "", domain.ErrUnauthorized
+	}
+	return v.Name, nil
+}
+
+func notificationCursor(n *domain.Notification) *domain.Cursor {
+	return &domain.Cursor{CreatedAt: n.CreatedAt, ID: n.ID}
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+	"github.com/yokitheyo/CommentTree/internal/pkg/mention"
+)
+
+// notifier превращает опубликованные комментарии в уведомления: упомянутым
+// пользователям и автору комментария, на который ответили. Ошибки только
+// логируются — комментарий к этому моменту уже сохранён.
+type notifier struct {
+	comments      domain.CommentRepository
+	notifications domain.NotificationRepository
+}
+
+// commentCreated запоминает упоминания; уведомления уходят, только если комментарий
+// сразу виден всем, иначе — после одобрения модератором
+func (n *notifier) commentCreated(ctx context.Context, c *domain.Comment) {
+	if err := n.notifications.SaveMentions(ctx, c.ID, mention.Extract(c.Content)); err != nil {
+		zlog.Logger.Warn().Err(err).Int64("comment_id", c.ID).Msg("notifier: save mentions failed")
+	}
+	if c.Status == domain.StatusApproved {
+		n.commentPublished(ctx, c)
+	}
+}
+
+func (n *notifier) commentPublished(ctx context.Context, c *domain.Comment) {
+	if c.Deleted {
+		return
+	}
+
+	// Себя не уведомляем, а автору родителя достаточно одного уведомления об ответе
+	notified := map[string]struct{}{c.Author: {}}
+	var list []*domain.Notification
+
+	add := func(recipient string, kind domain.NotificationKind) {
+		if _, ok := notified[recipient]; ok || recipient == "" {
+			return
+		}
+		notified[recipient] = struct{}{}
+		list = append(list, &domain.Notification{
+			Recipient: recipient,
+			Kind:      kind,
+			CommentID: c.ID,
+			ThreadKey: c.ThreadKey,
+			Actor:     c.Author,
+		})
+	}
+
+	if c.ParentID != nil {
+		parent, err := n.comments.FindByID(ctx, *c.ParentID)
+		if err != nil {
+			zlog.Logger.Warn().Err(err).Int64("comment_id", c.ID).Msg("notifier: find parent failed")
+		} else if !parent.Deleted {
+			add(parent.Author, domain.NotificationReply)
+		}
+	}
+	for _, name := range mention.Extract(c.Content) {
+		add(name, domain.NotificationMention)
+	}
+
+	if err := n.notifications.Create(ctx, list); err != nil {
+		zlog.Logger.Warn().Err(err).Int64("comment_id", c.ID).Msg("notifier: create notifications failed")
+		return
+	}
+	if len(list) > 0 {
+		zlog.Logger.Info().Msgf("notifier: %d notifications for comment id=%d", len(list), c.ID)
+	}
+}
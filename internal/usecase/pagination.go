@@ -0,0 +1,66 @@
+package usecase
+
+import "github.com/yokitheyo/CommentTree/internal/domain"
+
+// fetchOneMore запрашивает на один элемент больше страницы, чтобы узнать, есть ли продолжение
+func fetchOneMore(page domain.PageRequest) domain.PageRequest {
+	page.Limit++
+	return page
+}
+
+// paginate обрезает выборку, полученную с fetchOneMore, до размера страницы
+// и вычисляет курсоры соседних страниц.
+func paginate[T any](items []T, page domain.PageRequest, key func(T) *domain.Cursor) ([]T, *domain.Cursor, *domain.Cursor) {
+	hasMore := len(items) > page.Limit
+	backward := page.Before != nil
+
+	if hasMore {
+		if backward {
+			// При чтении назад лишний элемент оказывается самым ранним
+			items = items[len(items)-page.Limit:]
+		} else {
+			items = items[:page.Limit]
+		}
+	}
+
+	if len(items) == 0 {
+		return items, nil, nil
+	}
+
+	var next, prev *domain.Cursor
+	first, last := key(items[0]), key(items[len(items)-1])
+
+	if backward {
+		next = last
+		if hasMore {
+			prev = first
+		}
+	} else {
+		if hasMore {
+			next = last
+		}
+		if page.After != nil || page.Offset > 0 {
+			prev = first
+		}
+	}
+
+	return items, next, prev
+}
+
+// commentCursor строит курсор для выборки с заданной сортировкой; для сортировок
+// по голосам в курсор попадает ключ, посчитанный БД
+func commentCursor(sort string) func(*domain.Comment) *domain.Cursor {
+	return func(c *domain.Comment) *domain.Cursor {
+		cur := &domain.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+		if domain.IsScoreSort(sort) {
+			key := c.SortKey
+			cur.Score = &key
+		}
+		return cur
+	}
+}
+
+func searchHitCursor(h *domain.SearchHit) *domain.Cursor {
+	rank := h.Rank
+	return &domain.Cursor{CreatedAt: h.Comment.CreatedAt, ID: h.Comment.ID, Score: &rank}
+}
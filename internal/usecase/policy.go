@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// Action — действие над существующим комментарием, требующее прав
+type Action string
+
+const (
+	ActionEdit          Action = "edit"
+	ActionDelete        Action = "delete"
+	ActionDeleteSubtree Action = "delete_subtree"
+	ActionRestore       Action = "restore"
+	ActionModerate      Action = "moderate"
+	ActionLock          Action = "lock"
+)
+
+// Authorize решает, может ли читатель выполнить действие над комментарием:
+//   - администратор и модератор обсуждения комментария могут всё;
+//   - автор может править и удалять свой комментарий, но не чужие ответы на него;
+//     автор узнаётся по субъекту токена, совпадение имён прав не даёт;
+//   - восстановление, модерация и закрытие ветки доступны только модераторам;
+//   - анонимному читателю нужна аутентификация.
+func Authorize(v domain.Viewer, action Action, c *domain.Comment) error {
+	if v.Moderates(c.ThreadKey) {
+		return nil
+	}
+
+	switch action {
+	case ActionEdit, ActionDelete:
+		if v.Role == "" || v.Role == domain.RoleAnonymous || v.Subject == "" {
+			return domain.ErrUnauthorized
+		}
+		if !v.IsAuthor(c) {
+			return forbidden(action, "only the author can "+string(action)+" this comment")
+		}
+		return nil
+	case ActionDeleteSubtree:
+		return forbidden(action, "deleting replies of other authors requires moderator role")
+	default:
+		return forbidden(action, "moderator role required for thread "+c.ThreadKey)
+	}
+}
+
+func forbidden(action Action, reason string) error {
+	return &domain.ForbiddenError{Action: string(action), Reason: reason}
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+func TestAuthorize(t *testing.T) {
+	// Комментарий зарегистрированного alice и анонимный под тем же именем
+	owned := &domain.Comment{ThreadKey: "t", Author: "alice", AuthorSubject: "sub-alice"}
+	anonymous := &domain.Comment{ThreadKey: "t", Author: "alice"}
+
+	alice := domain.Viewer{Name: "alice", Subject: "sub-alice", Role: domain.RoleUser}
+	// Другой аккаунт SSO с тем же отображаемым именем
+	namesake := domain.Viewer{Name: "alice", Subject: "sub-other", Role: domain.RoleUser}
+	guest := domain.Viewer{Name: "alice", Role: domain.RoleAnonymous}
+	mod := domain.Viewer{Name: "mod", Subject: "sub-mod", Role: domain.RoleModerator, Threads: []string{"t"}}
+	otherMod := domain.Viewer{Name: "mod2", Subject: "sub-mod2", Role: domain.RoleModerator, Threads: []string{"u"}}
+
+	tests := []struct {
+		name    string
+		viewer  domain.Viewer
+		action  Action
+		comment *domain.Comment
+		wantErr error
+	}{
+		{"author edits own", alice, ActionEdit, owned, nil},
+		{"author deletes own", alice, ActionDelete, owned, nil},
+		{"author cannot delete subtree", alice, ActionDeleteSubtree, owned, domain.ErrForbidden},
+		{"author cannot restore", alice, ActionRestore, owned, domain.ErrForbidden},
+		{"same name, other subject", namesake, ActionEdit, owned, domain.ErrForbidden},
+		{"name does not own anonymous comment", alice, ActionEdit, anonymous, domain.ErrForbidden},
+		{"anonymous with the author name", guest, ActionEdit, owned, domain.ErrUnauthorized},
+		{"user without subject", domain.Viewer{Name: "alice", Role: domain.RoleUser}, ActionDelete, owned, domain.ErrUnauthorized},
+		{"thread moderator", mod, ActionDeleteSubtree, anonymous, nil},
+		{"moderator of another thread", otherMod, ActionModerate, owned, domain.ErrForbidden},
+		{"admin", domain.Viewer{Role: domain.RoleAdmin}, ActionLock, owned, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.viewer, tt.action, tt.comment)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authorize() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// SpamVerdict — оценка одного классификатора: чем больше Score, тем вероятнее спам
+type SpamVerdict struct {
+	Score   float64
+	Reasons []string
+}
+
+// SpamClassifier оценивает новый комментарий до сохранения
+type SpamClassifier interface {
+	Name() string
+	Classify(ctx context.Context, c *domain.Comment) (SpamVerdict, error)
+}
+
+// SpamSample — комментарий, размеченный модератором
+type SpamSample struct {
+	Text string
+	Spam bool
+}
+
+// TrainableClassifier — классификатор, который обучается на размеченных комментариях
+type TrainableClassifier interface {
+	SpamClassifier
+	Fit(samples []SpamSample) error
+}
+
+// SpamFilter прогоняет комментарий через цепочку классификаторов и суммирует их оценки.
+// Сумма не ниже pendingThreshold отправляет комментарий на модерацию, не ниже rejectThreshold — отклоняет.
+type SpamFilter struct {
+	classifiers      []SpamClassifier
+	pendingThreshold float64
+	rejectThreshold  float64
+}
+
+func NewSpamFilter(pendingThreshold, rejectThreshold float64, classifiers ...SpamClassifier) *SpamFilter {
+	return &SpamFilter{
+		classifiers:      classifiers,
+		pendingThreshold: pendingThreshold,
+		rejectThreshold:  rejectThreshold,
+	}
+}
+
+// Check суммирует оценки всех классификаторов. Ошибка одного классификатора
+// не мешает остальным: он просто пропускается.
+func (f *SpamFilter) Check(ctx context.Context, c *domain.Comment) SpamVerdict {
+	var total SpamVerdict
+	for _, cl := range f.classifiers {
+		v, err := cl.Classify(ctx, c)
+		if err != nil {
+			zlog.Logger.Warn().Err(err).Str("classifier", cl.Name()).Msg("spam classifier failed")
+			continue
+		}
+		if v.Score <= 0 {
+			continue
+		}
+		total.Score += v.Score
+		for _, reason := range v.Reasons {
+			total.Reasons = append(total.Reasons, cl.Name()+": "+reason)
+		}
+	}
+	return total
+}
+
+// Decide переводит суммарную оценку в статус модерации
+func (f *SpamFilter) Decide(v SpamVerdict) domain.ModerationStatus {
+	switch {
+	case f.rejectThreshold > 0 && v.Score >= f.rejectThreshold:
+		return domain.StatusRejected
+	case f.pendingThreshold > 0 && v.Score >= f.pendingThreshold:
+		return domain.StatusPending
+	}
+	return domain.StatusApproved
+}
+
+// Fit обучает все обучаемые классификаторы цепочки и возвращает их количество
+func (f *SpamFilter) Fit(samples []SpamSample) (int, error) {
+	trained := 0
+	for _, cl := range f.classifiers {
+		tc, ok := cl.(TrainableClassifier)
+		if !ok {
+			continue
+		}
+		if err := tc.Fit(samples); err != nil {
+			return trained, fmt.Errorf("fit %s: %w", cl.Name(), err)
+		}
+		trained++
+	}
+	return trained, nil
+}
+
+// apply проверяет комментарий и при подозрении на спам меняет его статус
+func (f *SpamFilter) apply(ctx context.Context, c *domain.Comment) {
+	verdict := f.Check(ctx, c)
+	status := f.Decide(verdict)
+	if status == domain.StatusApproved {
+		return
+	}
+
+	zlog.Logger.Info().
+		Float64("score", verdict.Score).
+		Strs("reasons", verdict.Reasons).
+		Str("author", c.Author).
+		Msgf("spam filter: comment marked %s", status)
+
+	reason := strings.Join(verdict.Reasons, "; ")
+	c.Status = status
+	c.ModerationReason = &reason
+	// В очереди решения модератора нет, даже если раньше комментарий был одобрен
+	c.ModeratedBy, c.ModeratedAt = nil, nil
+	if status == domain.StatusRejected {
+		moderator := domain.SpamFilterModerator
+		now := time.Now()
+		c.ModeratedBy = &moderator
+		c.ModeratedAt = &now
+	}
+}
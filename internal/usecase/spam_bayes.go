@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// NaiveBayesClassifier — мультиномиальный наивный Байес по словам комментария.
+// Пока модель не обучена на обоих классах, классификатор ничего не штрафует.
+type NaiveBayesClassifier struct {
+	weight     float64
+	minSamples int
+
+	mu     sync.RWMutex
+	words  [2]map[string]int // 0 — ham, 1 — spam
+	totals [2]int
+	docs   [2]int
+}
+
+// NewNaiveBayesClassifier создаёт необученный классификатор; minSamples — сколько
+// примеров каждого класса нужно, чтобы модель начала влиять на оценку.
+func NewNaiveBayesClassifier(weight float64, minSamples int) *NaiveBayesClassifier {
+	return &NaiveBayesClassifier{weight: weight, minSamples: max(minSamples, 1)}
+}
+
+func (nb *NaiveBayesClassifier) Name() string { return "naive_bayes" }
+
+// Fit заменяет модель новой, обученной на samples
+func (nb *NaiveBayesClassifier) Fit(samples []SpamSample) error {
+	words := [2]map[string]int{{}, {}}
+	var totals, docs [2]int
+
+	for _, s := range samples {
+		class := 0
+		if s.Spam {
+			class = 1
+		}
+		docs[class]++
+		for _, w := range tokenize(s.Text) {
+			words[class][w]++
+			totals[class]++
+		}
+	}
+
+	nb.mu.Lock()
+	nb.words, nb.totals, nb.docs = words, totals, docs
+	nb.mu.Unlock()
+	return nil
+}
+
+func (nb *NaiveBayesClassifier) Classify(_ context.Context, c *domain.Comment) (SpamVerdict, error) {
+	p, ok := nb.spamProbability(tokenize(c.Content))
+	// 0.5 — модель не знает; штрафуем только за уверенность в спаме
+	if !ok || p <= 0.5 {
+		return SpamVerdict{}, nil
+	}
+	return SpamVerdict{
+		Score:   nb.weight * (2*p - 1),
+		Reasons: []string{fmt.Sprintf("spam probability %.2f", p)},
+	}, nil
+}
+
+func (nb *NaiveBayesClassifier) spamProbability(tokens []string) (float64, bool) {
+	nb.mu.RLock()
+	defer nb.mu.RUnlock()
+
+	if nb.docs[0] < nb.minSamples || nb.docs[1] < nb.minSamples || len(tokens) == 0 {
+		return 0, false
+	}
+
+	vocabulary := len(nb.words[0])
+	for w := range nb.words[1] {
+		if _, ok := nb.words[0][w]; !ok {
+			vocabulary++
+		}
+	}
+
+	// Незнакомые слова пропускаем: после сглаживания они тянули бы оценку
+	// к классу с меньшим числом слов, хотя ничего о тексте не говорят
+	var known []string
+	for _, w := range tokens {
+		if nb.words[0][w] > 0 || nb.words[1][w] > 0 {
+			known = append(known, w)
+		}
+	}
+	if len(known) == 0 {
+		return 0, false
+	}
+
+	// Логарифмы, чтобы длинные тексты не уходили в ноль; сглаживание Лапласа
+	var logp [2]float64
+	allDocs := float64(nb.docs[0] + nb.docs[1])
+	for class := 0; class < 2; class++ {
+		logp[class] = math.Log(float64(nb.docs[class]) / allDocs)
+		denom := float64(nb.totals[class] + vocabulary)
+		for _, w := range known {
+			logp[class] += math.Log(float64(nb.words[class][w]+1) / denom)
+		}
+	}
+
+	return 1 / (1 + math.Exp(logp[0]-logp[1])), true
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+func TestNaiveBayesClassifier(t *testing.T) {
+	samples := []SpamSample{
+		{Text: "cheap casino bonus click now", Spam: true},
+		{Text: "win money casino free bonus", Spam: true},
+		{Text: "great article thanks for sharing", Spam: false},
+		{Text: "I agree with the author on this point", Spam: false},
+	}
+
+	tests := []struct {
+		name       string
+		samples    []SpamSample
+		minSamples int
+		content    string
+		wantSpam   bool // ожидается положительная оценка
+	}{
+		{"empty model", nil, 1, "casino bonus", false},
+		{"one class only", samples[:2], 1, "casino bonus", false},
+		{"too few samples", samples, 3, "casino bonus", false},
+		{"spam", samples, 2, "free casino bonus now", true},
+		{"ham", samples, 2, "thanks for the article", false},
+		{"unknown words", samples, 2, "zebra quantum", false},
+		{"no words", samples, 2, "!!! ...", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nb := NewNaiveBayesClassifier(2, tt.minSamples)
+			if err := nb.Fit(tt.samples); err != nil {
+				t.Fatal(err)
+			}
+			v, err := nb.Classify(context.Background(), &domain.Comment{Content: tt.content})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (v.Score > 0) != tt.wantSpam {
+				t.Fatalf("score = %v, want spam = %v", v.Score, tt.wantSpam)
+			}
+			if v.Score < 0 || v.Score > 2 {
+				t.Fatalf("score = %v outside [0, weight]", v.Score)
+			}
+		})
+	}
+}
+
+func TestNaiveBayesProbability(t *testing.T) {
+	nb := NewNaiveBayesClassifier(1, 1)
+	_ = nb.Fit([]SpamSample{{Text: "spam spam", Spam: true}, {Text: "ham ham", Spam: false}})
+
+	tests := []struct {
+		tokens []string
+		want   float64
+	}{
+		// P(spam|w) = (3/5) / (3/5 + 1/5)
+		{[]string{"spam"}, 0.75},
+		{[]string{"ham"}, 0.25},
+		{[]string{"spam", "ham"}, 0.5},
+		// Незнакомые слова не влияют на оценку
+		{[]string{"spam", "other", "words"}, 0.75},
+	}
+	for _, tt := range tests {
+		got, ok := nb.spamProbability(tt.tokens)
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("spamProbability(%q) = %v, %v; want %v", tt.tokens, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := nb.spamProbability([]string{"other"}); ok {
+		t.Fatal("text of unknown words classified")
+	}
+
+	// Повторное обучение заменяет модель, а не дополняет её
+	_ = nb.Fit(nil)
+	if _, ok := nb.spamProbability([]string{"spam"}); ok {
+		t.Fatal("model kept after Fit(nil)")
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := strings.Join(tokenize("Hello, WORLD! Привет—мир 42x"), " ")
+	if want := "hello world привет мир 42x"; got != want {
+		t.Fatalf("tokenize() = %q, want %q", got, want)
+	}
+}
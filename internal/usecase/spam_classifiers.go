@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinkClassifier штрафует за каждую ссылку сверх допустимого количества
+type LinkClassifier struct {
+	maxLinks int
+	weight   float64
+}
+
+func NewLinkClassifier(maxLinks int, weight float64) *LinkClassifier {
+	return &LinkClassifier{maxLinks: maxLinks, weight: weight}
+}
+
+func (l *LinkClassifier) Name() string { return "links" }
+
+func (l *LinkClassifier) Classify(_ context.Context, c *domain.Comment) (SpamVerdict, error) {
+	n := len(linkPattern.FindAllStringIndex(c.Content, -1))
+	if n <= l.maxLinks {
+		return SpamVerdict{}, nil
+	}
+	return SpamVerdict{
+		Score:   float64(n-l.maxLinks) * l.weight,
+		Reasons: []string{fmt.Sprintf("%d links (max %d)", n, l.maxLinks)},
+	}, nil
+}
+
+// BlocklistClassifier ищет запрещённые слова и регулярные выражения в тексте и имени автора
+type BlocklistClassifier struct {
+	keywords []string
+	patterns []*regexp.Regexp
+	weight   float64
+}
+
+// NewBlocklistClassifier компилирует шаблоны; слова сравниваются без учёта регистра.
+func NewBlocklistClassifier(keywords, patterns []string, weight float64) (*BlocklistClassifier, error) {
+	b := &BlocklistClassifier{weight: weight}
+	for _, k := range keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			b.keywords = append(b.keywords, k)
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile blocklist pattern %q: %w", p, err)
+		}
+		b.patterns = append(b.patterns, re)
+	}
+	return b, nil
+}
+
+func (b *BlocklistClassifier) Name() string { return "blocklist" }
+
+func (b *BlocklistClassifier) Classify(_ context.Context, c *domain.Comment) (SpamVerdict, error) {
+	text := c.Author + "\n" + c.Content
+	lower := strings.ToLower(text)
+
+	var v SpamVerdict
+	for _, k := range b.keywords {
+		if strings.Contains(lower, k) {
+			v.Score += b.weight
+			v.Reasons = append(v.Reasons, fmt.Sprintf("keyword %q", k))
+		}
+	}
+	for _, re := range b.patterns {
+		if re.MatchString(text) {
+			v.Score += b.weight
+			v.Reasons = append(v.Reasons, fmt.Sprintf("pattern %q", re.String()))
+		}
+	}
+	return v, nil
+}
+
+// RepeatedCharClassifier ловит «ааааааа!!!!!!» — длинные серии одного и того же символа
+type RepeatedCharClassifier struct {
+	maxRun int
+	weight float64
+}
+
+func NewRepeatedCharClassifier(maxRun int, weight float64) *RepeatedCharClassifier {
+	return &RepeatedCharClassifier{maxRun: maxRun, weight: weight}
+}
+
+func (r *RepeatedCharClassifier) Name() string { return "repeated_chars" }
+
+func (r *RepeatedCharClassifier) Classify(_ context.Context, c *domain.Comment) (SpamVerdict, error) {
+	longest, run := 0, 0
+	var prev rune
+	for i, ch := range c.Content {
+		if unicode.IsSpace(ch) {
+			run = 0
+			continue
+		}
+		if i > 0 && unicode.ToLower(ch) == unicode.ToLower(prev) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = ch
+		longest = max(longest, run)
+	}
+
+	if longest <= r.maxRun {
+		return SpamVerdict{}, nil
+	}
+	return SpamVerdict{
+		Score:   r.weight,
+		Reasons: []string{fmt.Sprintf("%d repeated characters in a row (max %d)", longest, r.maxRun)},
+	}, nil
+}
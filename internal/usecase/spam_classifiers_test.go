@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// classifierCase — текст комментария и ожидаемая оценка классификатора
+type classifierCase struct {
+	name    string
+	author  string
+	content string
+	want    float64
+}
+
+func runClassifierCases(t *testing.T, cl SpamClassifier, tests []classifierCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := cl.Classify(context.Background(), &domain.Comment{Author: tt.author, Content: tt.content})
+			if err != nil {
+				t.Fatalf("Classify() error = %v", err)
+			}
+			if math.Abs(v.Score-tt.want) > 1e-9 {
+				t.Fatalf("score = %v, want %v (reasons %q)", v.Score, tt.want, v.Reasons)
+			}
+			if (v.Score > 0) != (len(v.Reasons) > 0) {
+				t.Fatalf("score %v with reasons %q", v.Score, v.Reasons)
+			}
+		})
+	}
+}
+
+func TestLinkClassifier(t *testing.T) {
+	runClassifierCases(t, NewLinkClassifier(1, 0.5), []classifierCase{
+		{"no links", "", "plain text", 0},
+		{"within the limit", "", "see https://a.example", 0},
+		{"one over", "", "https://a.example and http://b.example", 0.5},
+		{"www and case", "", "HTTPS://A.example www.b.example www.c.example", 1},
+		{"domain without scheme is not a link", "", "a.example b.example", 0},
+	})
+}
+
+func TestBlocklistClassifier(t *testing.T) {
+	cl, err := NewBlocklistClassifier([]string{" Casino ", "", "bonus"}, []string{`\d{3}-\d{4}`}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runClassifierCases(t, cl, []classifierCase{
+		{"clean", "alice", "hello", 0},
+		{"keyword ignores case", "alice", "Best CASINO", 1},
+		{"keyword counted once", "alice", "casino casino", 1},
+		{"keyword in author", "casino-bot", "hello", 1},
+		{"keywords and pattern add up", "alice", "casino bonus, call 555-1234", 3},
+	})
+
+	if _, err := NewBlocklistClassifier(nil, []string{"("}, 1); err == nil {
+		t.Fatal("NewBlocklistClassifier accepted an invalid pattern")
+	}
+}
+
+func TestRepeatedCharClassifier(t *testing.T) {
+	runClassifierCases(t, NewRepeatedCharClassifier(3, 2), []classifierCase{
+		{"normal text", "", "hello world", 0},
+		{"at the limit", "", "wow!!!", 0},
+		{"over the limit", "", "wow!!!!", 2},
+		{"case-insensitive", "", "aAaA", 2},
+		{"cyrillic", "", "дааааа", 2},
+		{"spaces break runs", "", "!!! !!! !!!", 0},
+	})
+}
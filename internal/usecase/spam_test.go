@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// stubClassifier возвращает заданную оценку или ошибку
+type stubClassifier struct {
+	name  string
+	score float64
+	err   error
+}
+
+func (s stubClassifier) Name() string { return s.name }
+
+func (s stubClassifier) Classify(context.Context, *domain.Comment) (SpamVerdict, error) {
+	return SpamVerdict{Score: s.score, Reasons: []string{"stub"}}, s.err
+}
+
+func TestSpamFilterDecide(t *testing.T) {
+	tests := []struct {
+		name            string
+		pending, reject float64
+		score           float64
+		want            domain.ModerationStatus
+	}{
+		{"below both", 1, 2, 0.9, domain.StatusApproved},
+		{"at pending", 1, 2, 1, domain.StatusPending},
+		{"between", 1, 2, 1.5, domain.StatusPending},
+		{"at reject", 1, 2, 2, domain.StatusRejected},
+		{"above reject", 1, 2, 10, domain.StatusRejected},
+		{"pending disabled", 0, 2, 1.5, domain.StatusApproved},
+		{"reject disabled", 1, 0, 10, domain.StatusPending},
+		{"both disabled", 0, 0, 10, domain.StatusApproved},
+		{"zero score never flagged", 0, 0, 0, domain.StatusApproved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewSpamFilter(tt.pending, tt.reject)
+			if got := f.Decide(SpamVerdict{Score: tt.score}); got != tt.want {
+				t.Fatalf("Decide(%v) = %s, want %s", tt.score, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpamFilterCheck(t *testing.T) {
+	f := NewSpamFilter(1, 2,
+		stubClassifier{name: "a", score: 0.5},
+		stubClassifier{name: "broken", score: 5, err: errors.New("down")},
+		stubClassifier{name: "negative", score: -3},
+		stubClassifier{name: "b", score: 1},
+	)
+	v := f.Check(context.Background(), &domain.Comment{})
+	// Сломанный классификатор пропускается, отрицательная оценка не снижает сумму
+	if v.Score != 1.5 || len(v.Reasons) != 2 || v.Reasons[0] != "a: stub" || v.Reasons[1] != "b: stub" {
+		t.Fatalf("Check() = %+v", v)
+	}
+}
@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// noTx выполняет шаги без общей транзакции — пока Transactor не задан
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func orNoTx(tx domain.Transactor) domain.Transactor {
+	if tx == nil {
+		return noTx{}
+	}
+	return tx
+}
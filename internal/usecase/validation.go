@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// ValidationRules — ограничения на пользовательский ввод; нулевые значения снимают ограничение
+type ValidationRules struct {
+	MaxContentLength   int
+	MaxAuthorLength    int
+	MaxThreadKeyLength int
+	// Категории Unicode (как в unicode.Categories), из символов которых может состоять текст
+	ContentCategories []string
+	AuthorCategories  []string
+	// MaxDepth — наибольший уровень вложенности; корневой комментарий на уровне 1
+	MaxDepth int
+}
+
+// Validator нормализует и проверяет текстовые поля комментария
+type Validator struct {
+	rules   ValidationRules
+	content []*unicode.RangeTable
+	author  []*unicode.RangeTable
+}
+
+// contentExtras разрешены в тексте всегда: переводы строк, табуляция и
+// соединители (ZWNJ/ZWJ), без которых ломаются эмодзи и некоторые письменности
+var contentExtras = []rune{'\n', '\t', '\u200c', '\u200d'}
+
+func NewValidator(rules ValidationRules) (*Validator, error) {
+	content, err := categoryTables(rules.ContentCategories)
+	if err != nil {
+		return nil, fmt.Errorf("content categories: %w", err)
+	}
+	author, err := categoryTables(rules.AuthorCategories)
+	if err != nil {
+		return nil, fmt.Errorf("author categories: %w", err)
+	}
+	return &Validator{rules: rules, content: content, author: author}, nil
+}
+
+func categoryTables(names []string) ([]*unicode.RangeTable, error) {
+	tables := make([]*unicode.RangeTable, 0, len(names))
+	for _, name := range names {
+		t, ok := unicode.Categories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown unicode category %q", name)
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// normalize обрезает пробелы по краям и приводит строку к NFC
+func normalize(s string) string {
+	return norm.NFC.String(strings.TrimSpace(s))
+}
+
+// Content нормализует текст комментария (в том числе переводы строк к \n) и проверяет его;
+// ошибки добавляются в verr под именем поля content
+func (v *Validator) Content(verr *domain.ValidationError, content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = normalize(strings.ReplaceAll(content, "\r", "\n"))
+	if content == "" {
+		verr.Add("content", "required")
+		return content
+	}
+	v.check(verr, "content", content, v.rules.MaxContentLength, v.content, contentExtras)
+	return content
+}
+
+// Name нормализует и проверяет имя автора или редактора
+func (v *Validator) Name(verr *domain.ValidationError, field, name string) string {
+	name = normalize(name)
+	if name != "" {
+		v.check(verr, field, name, v.rules.MaxAuthorLength, v.author, nil)
+	}
+	return name
+}
+
+// ThreadKey нормализует и проверяет ключ обсуждения; категории для него не настраиваются,
+// запрещены только управляющие символы
+func (v *Validator) ThreadKey(verr *domain.ValidationError, key string) string {
+	key = normalize(key)
+	if key != "" {
+		v.check(verr, "thread_key", key, v.rules.MaxThreadKeyLength, nil, nil)
+	}
+	return key
+}
+
+// Depth проверяет, что ответ на комментарий с parentAncestors предками не превысит MaxDepth
+func (v *Validator) Depth(parentAncestors int) error {
+	depth := parentAncestors + 2
+	if v.rules.MaxDepth > 0 && depth > v.rules.MaxDepth {
+		return domain.Invalid("parent_id", fmt.Sprintf("replies cannot be nested deeper than %d levels", v.rules.MaxDepth))
+	}
+	return nil
+}
+
+// check добавляет в verr не более одной ошибки поля: о длине или о первом недопустимом символе
+func (v *Validator) check(verr *domain.ValidationError, field, s string, maxLen int, allowed []*unicode.RangeTable, extras []rune) {
+	if !utf8.ValidString(s) {
+		verr.Add(field, "must be valid UTF-8")
+		return
+	}
+	if n := utf8.RuneCountInString(s); maxLen > 0 && n > maxLen {
+		verr.Add(field, fmt.Sprintf("must not exceed %d characters (got %d)", maxLen, n))
+		return
+	}
+	for _, r := range s {
+		if !allowedRune(r, allowed, extras) {
+			verr.Add(field, fmt.Sprintf("contains disallowed character %U", r))
+			return
+		}
+	}
+}
+
+func allowedRune(r rune, allowed []*unicode.RangeTable, extras []rune) bool {
+	for _, e := range extras {
+		if r == e {
+			return true
+		}
+	}
+	if len(allowed) == 0 {
+		return !unicode.Is(unicode.C, r)
+	}
+	return unicode.In(r, allowed...)
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+func newTestValidator(t *testing.T) *Validator {
+	t.Helper()
+	v, err := NewValidator(ValidationRules{
+		MaxContentLength:   20,
+		MaxAuthorLength:    8,
+		MaxThreadKeyLength: 10,
+		ContentCategories:  []string{"L", "N", "P", "Zs"},
+		AuthorCategories:   []string{"L", "N"},
+		MaxDepth:           3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// fieldErrors возвращает сообщения об ошибках поля field
+func fieldErrors(verr *domain.ValidationError, field string) []string {
+	var out []string
+	for _, f := range verr.Fields {
+		if f.Field == field {
+			out = append(out, f.Message)
+		}
+	}
+	return out
+}
+
+func TestValidatorContent(t *testing.T) {
+	v := newTestValidator(t)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string // подстрока сообщения; пусто — без ошибки
+	}{
+		{"trimmed", "  hello  ", "hello", ""},
+		{"crlf and cr become lf", "a\r\nb\rc", "a\nb\nc", ""},
+		{"nfc", "e\u0301", "\u00e9", ""},
+		{"tab and joiners allowed", "a\tb\u200dc", "a\tb\u200dc", ""},
+		{"empty", "   ", "", "required"},
+		{"length counted in characters", strings.Repeat("я", 20), strings.Repeat("я", 20), ""},
+		{"too long", strings.Repeat("a", 21), strings.Repeat("a", 21), "must not exceed 20 characters (got 21)"},
+		{"disallowed symbol", "1 + 1", "1 + 1", "U+002B"},
+		{"control character", "a\u0007b", "a\u0007b", "U+0007"},
+		{"invalid utf-8", "a\xffb", "", "valid UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &domain.ValidationError{}
+			got := v.Content(verr, tt.in)
+			errs := fieldErrors(verr, "content")
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("Content(%q) errors = %v", tt.in, errs)
+				}
+				if got != tt.want {
+					t.Fatalf("Content(%q) = %q, want %q", tt.in, got, tt.want)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0], tt.wantErr) {
+				t.Fatalf("Content(%q) errors = %v, want one containing %q", tt.in, errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorName(t *testing.T) {
+	v := newTestValidator(t)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"letters and digits", " Alice42 ", "Alice42", false},
+		{"cyrillic", "Мария", "Мария", false},
+		{"empty is allowed", "", "", false},
+		{"space inside", "Al ice", "Al ice", true},
+		{"markup", "<b>", "<b>", true},
+		{"too long", "Alexandra", "Alexandra", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &domain.ValidationError{}
+			got := v.Name(verr, "author", tt.in)
+			if got != tt.want {
+				t.Fatalf("Name(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if hasErr := len(fieldErrors(verr, "author")) > 0; hasErr != tt.wantErr {
+				t.Fatalf("Name(%q) errors = %v, want error = %v", tt.in, verr.Fields, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorThreadKey(t *testing.T) {
+	v := newTestValidator(t)
+
+	tests := []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{
+		{"url-like key", "/a/b?c=1", false},
+		{"any printable symbols", "post#42 ✓", false},
+		{"control character", "a\nb", true},
+		{"too long", "article-1234", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &domain.ValidationError{}
+			v.ThreadKey(verr, tt.in)
+			if hasErr := len(fieldErrors(verr, "thread_key")) > 0; hasErr != tt.wantErr {
+				t.Fatalf("ThreadKey(%q) errors = %v, want error = %v", tt.in, verr.Fields, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorDepth(t *testing.T) {
+	v := newTestValidator(t)
+
+	// Ответ на корневой комментарий (0 предков) — второй уровень
+	for ancestors, wantErr := range []bool{false, false, true, true} {
+		if err := v.Depth(ancestors); (err != nil) != wantErr {
+			t.Errorf("Depth(%d) error = %v, want error = %v", ancestors, err, wantErr)
+		}
+	}
+
+	if err := (&Validator{}).Depth(100); err != nil {
+		t.Errorf("Depth without limit error = %v", err)
+	}
+}
+
+func TestNewValidatorUnknownCategory(t *testing.T) {
+	if _, err := NewValidator(ValidationRules{ContentCategories: []string{"L", "Bogus"}}); err == nil {
+		t.Fatal("NewValidator accepted an unknown category")
+	}
+}
+
+func TestValidatorWithoutCategories(t *testing.T) {
+	// Без категорий запрещены только управляющие и прочие символы категории C
+	v := &Validator{}
+	verr := &domain.ValidationError{}
+	v.Content(verr, "<b>any</b> symbols © ✓")
+	v.Content(verr, "bell\u0007")
+	if errs := fieldErrors(verr, "content"); len(errs) != 1 || !strings.Contains(errs[0], "U+0007") {
+		t.Fatalf("errors = %v, want only the control character", errs)
+	}
+}
@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+	"github.com/yokitheyo/CommentTree/internal/domain"
+)
+
+// ActionManageWebhooks — управление подписками вебхуков, только для администраторов
+const ActionManageWebhooks Action = "manage_webhooks"
+
+// webhookEventNames сопоставляет внутренние события с событиями вебхуков
+var webhookEventNames = map[domain.CommentEventType]string{
+	domain.EventCommentCreated:   domain.WebhookCommentCreated,
+	domain.EventCommentEdited:    domain.WebhookCommentUpdated,
+	domain.EventCommentRestored:  domain.WebhookCommentUpdated,
+	domain.EventCommentLocked:    domain.WebhookCommentUpdated,
+	domain.EventCommentDeleted:   domain.WebhookCommentDeleted,
+	domain.EventCommentModerated: domain.WebhookCommentModerated,
+}
+
+// WebhookPayload — тело запроса, которое получает вебхук
+type WebhookPayload struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Comment    *domain.Comment `json:"comment"`
+	// Subtree — удалено или восстановлено всё поддерево комментария
+	Subtree bool `json:"subtree,omitempty"`
+}
+
+type WebhookUsecase struct {
+	repo domain.WebhookRepository
+}
+
+func NewWebhookUsecase(repo domain.WebhookRepository) *WebhookUsecase {
+	return &WebhookUsecase{repo: repo}
+}
+
+// EnqueueEvent ставит событие из outbox в очередь доставки подписанным вебхукам.
+// Вызывается в транзакции разбора outbox; повтор события с тем же ID в очередь не попадает.
+func (u *WebhookUsecase) EnqueueEvent(ctx context.Context, e *domain.CommentEvent) error {
+	name, ok := webhookEventNames[e.Type]
+	if !ok || e.ID == "" || e.Comment == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(WebhookPayload{
+		ID:         e.ID,
+		Type:       name,
+		OccurredAt: e.At,
+		Comment:    e.Comment,
+		Subtree:    e.Subtree,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload for event %s: %w", e.ID, err)
+	}
+
+	n, err := u.repo.Enqueue(ctx, e.ID, name, payload)
+	if err != nil {
+		return fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	if n > 0 {
+		zlog.Logger.Debug().Str("event_id", e.ID).Str("type", name).Int64("deliveries", n).Msg("webhooks: event enqueued")
+	}
+	return nil
+}
+
+func (u *WebhookUsecase) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	hooks, err := u.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+func (u *WebhookUsecase) GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return u.repo.FindByID(ctx, id)
+}
+
+// CreateWebhook сохраняет подписку; без секрета генерирует его — секрет
+// возвращается в ответе, чтобы получатель мог проверять подпись.
+func (u *WebhookUsecase) CreateWebhook(ctx context.Context, w *domain.Webhook) (*domain.Webhook, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := normalizeWebhook(w); err != nil {
+		return nil, err
+	}
+	if w.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		w.Secret = secret
+	}
+
+	if err := u.repo.Create(ctx, w); err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+
+	zlog.Logger.Info().Msgf("webhook created id=%d url=%s events=%v", w.ID, w.URL, w.Events)
+	return w, nil
+}
+
+// UpdateWebhook меняет адрес, события и активность; пустой секрет оставляет прежний
+func (u *WebhookUsecase) UpdateWebhook(ctx context.Context, w *domain.Webhook) (*domain.Webhook, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := normalizeWebhook(w); err != nil {
+		return nil, err
+	}
+	if w.Secret == "" {
+		current, err := u.repo.FindByID(ctx, w.ID)
+		if err != nil {
+			return nil, err
+		}
+		w.Secret = current.Secret
+	}
+
+	if err := u.repo.Update(ctx, w); err != nil {
+		return nil, fmt.Errorf("update webhook id=%d: %w", w.ID, err)
+	}
+
+	zlog.Logger.Info().Msgf("webhook updated id=%d url=%s active=%t", w.ID, w.URL, w.Active)
+	return w, nil
+}
+
+func (u *WebhookUsecase) DeleteWebhook(ctx context.Context, id int64) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if err := u.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete webhook id=%d: %w", id, err)
+	}
+	zlog.Logger.Info().Msgf("webhook deleted id=%d", id)
+	return nil
+}
+
+// ListDeliveries возвращает журнал доставок вебхука, новые первыми
+func (u *WebhookUsecase) ListDeliveries(ctx context.Context, webhookID int64, status domain.DeliveryStatus, page domain.PageRequest) (*domain.DeliveryPage, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryDead:
+	default:
+		return nil, domain.Invalid("status", fmt.Sprintf("unknown delivery status %q", status))
+	}
+	if _, err := u.repo.FindByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	items, err := u.repo.ListDeliveries(ctx, webhookID, status, fetchOneMore(page))
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+
+	items, next, prev := paginate(items, page, deliveryCursor)
+	return &domain.DeliveryPage{Items: items, Next: next, Prev: prev}, nil
+}
+
+// GetDelivery возвращает доставку вместе с журналом попыток
+func (u *WebhookUsecase) GetDelivery(ctx context.Context, webhookID, deliveryID int64) (*domain.WebhookDelivery, []*domain.DeliveryAttempt, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, nil, err
+	}
+	d, err := u.repo.FindDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	attempts, err := u.repo.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list attempts: %w", err)
+	}
+	return d, attempts, nil
+}
+
+// ReplayDelivery возвращает доставку (обычно из dead-letter) в очередь
+func (u *WebhookUsecase) ReplayDelivery(ctx context.Context, webhookID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	d, err := u.repo.Replay(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	zlog.Logger.Info().Msgf("webhook delivery replayed id=%d webhook=%d event=%s", d.ID, webhookID, d.EventID)
+	return d, nil
+}
+
+func requireAdmin(ctx context.Context) error {
+	v := domain.ViewerFromContext(ctx)
+	switch v.Role {
+	case domain.RoleAdmin:
+		return nil
+	case "", domain.RoleAnonymous:
+		return domain.ErrUnauthorized
+	}
+	return forbidden(ActionManageWebhooks, "admin role required")
+}
+
+func normalizeWebhook(w *domain.Webhook) error {
+	verr := &domain.ValidationError{}
+	w.URL = strings.TrimSpace(w.URL)
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		verr.Add("url", "must be an absolute http or https URL")
+	}
+
+	events := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		e = strings.TrimSpace(e)
+		if !slices.Contains(domain.WebhookEvents, e) {
+			verr.Add("events", fmt.Sprintf("unknown event %q", e))
+			continue
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	w.Events = events
+	return verr.Err()
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func deliveryCursor(d *domain.WebhookDelivery) *domain.Cursor {
+	return &domain.Cursor{CreatedAt: d.CreatedAt, ID: d.ID}
+}
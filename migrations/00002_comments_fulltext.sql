@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION comments_content_tsv_update() RETURNS trigger AS $$
+BEGIN
+    NEW.content_tsv :=
+        setweight(to_tsvector('simple', coalesce(NEW.content, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.author, '')), 'B');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER comments_content_tsv_trigger
+    BEFORE INSERT OR UPDATE OF content, author ON comments
+    FOR EACH ROW EXECUTE FUNCTION comments_content_tsv_update();
+
+-- Уже существующие комментарии индексирует пачками фоновая задача после запуска
+-- (search.Backfill): переписывать всю таблицу внутри миграции слишком долго.
+-- Конфигурация 'simple' выбрана намеренно, см. repository.TextSearchConfig.
+
+-- +goose Down
+DROP TRIGGER IF EXISTS comments_content_tsv_trigger ON comments;
+DROP FUNCTION IF EXISTS comments_content_tsv_update();
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS edited_by TEXT,
+    ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    revision INT NOT NULL,
+    content TEXT NOT NULL,
+    editor TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (comment_id, revision)
+    );
+
+-- +goose Down
+DROP TABLE IF EXISTS comment_revisions;
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS edited_at,
+    DROP COLUMN IF EXISTS edited_by;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS thread_key TEXT;
+
+-- Всё, что существовало до появления ключей, считается одним обсуждением
+UPDATE comments SET thread_key = 'default' WHERE thread_key IS NULL;
+
+ALTER TABLE comments ALTER COLUMN thread_key SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_comments_thread_key ON comments(thread_key, created_at);
+CREATE INDEX IF NOT EXISTS idx_comments_thread_roots ON comments(thread_key, created_at, id) WHERE parent_id IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_thread_roots;
+DROP INDEX IF EXISTS idx_comments_thread_key;
+ALTER TABLE comments DROP COLUMN IF EXISTS thread_key;
@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS upvotes INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS downvotes INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS score INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS comment_votes (
+    comment_id BIGINT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    voter TEXT NOT NULL,
+    value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (comment_id, voter)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_comments_score ON comments(score);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_score;
+DROP TABLE IF EXISTS comment_votes;
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS score,
+    DROP COLUMN IF EXISTS downvotes,
+    DROP COLUMN IF EXISTS upvotes;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Хранится только SHA-256 от токена; сам токен выдаётся автору один раз при создании
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS management_token_hash BYTEA;
+
+-- +goose Down
+ALTER TABLE comments DROP COLUMN IF EXISTS management_token_hash;
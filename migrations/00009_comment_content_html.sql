@@ -0,0 +1,6 @@
+-- +goose Up
+-- Отрендеренный Markdown; NULL у комментариев, написанных до появления рендеринга
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_html TEXT;
+
+-- +goose Down
+ALTER TABLE comments DROP COLUMN IF EXISTS content_html;
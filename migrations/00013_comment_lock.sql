@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS locked;
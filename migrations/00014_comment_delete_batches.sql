@@ -0,0 +1,18 @@
+-- +goose Up
+-- deleted_batch связывает строки, удалённые одним запросом: каскадное восстановление
+-- возвращает только их, а не ответы, которые авторы удалили сами.
+-- У удалённых до миграции комментариев номера нет, каскад их не восстанавливает.
+CREATE SEQUENCE IF NOT EXISTS comment_delete_batches;
+
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS deleted_batch BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_batch ON comments(deleted_batch) WHERE deleted_batch IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_deleted_batch;
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS deleted_batch;
+
+DROP SEQUENCE IF EXISTS comment_delete_batches;
@@ -0,0 +1,59 @@
+-- +goose Up
+-- Сводка по обсуждениям поддерживается триггером, чтобы список обсуждений не
+-- группировал всю таблицу комментариев. Учитываются только одобренные и неудалённые.
+CREATE TABLE IF NOT EXISTS thread_summaries (
+    thread_key TEXT PRIMARY KEY,
+    comment_count BIGINT NOT NULL DEFAULT 0,
+    last_comment_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_summaries_recent ON thread_summaries(last_comment_at DESC, thread_key) WHERE comment_count > 0;
+-- Новое время последнего комментария после удаления или отклонения ищется по этому индексу
+CREATE INDEX IF NOT EXISTS idx_comments_thread_visible ON comments(thread_key, created_at) WHERE status = 'approved' AND NOT deleted;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION thread_summaries_update() RETURNS trigger AS $$
+DECLARE
+    was_visible BOOLEAN := TG_OP <> 'INSERT' AND OLD.status = 'approved' AND NOT OLD.deleted;
+    is_visible BOOLEAN := TG_OP <> 'DELETE' AND NEW.status = 'approved' AND NOT NEW.deleted;
+    moved BOOLEAN := TG_OP = 'UPDATE' AND OLD.thread_key <> NEW.thread_key;
+BEGIN
+    IF was_visible AND (NOT is_visible OR moved) THEN
+        UPDATE thread_summaries
+        SET comment_count = comment_count - 1,
+            last_comment_at = (
+                SELECT max(created_at) FROM comments
+                WHERE thread_key = OLD.thread_key AND status = 'approved' AND NOT deleted
+            )
+        WHERE thread_key = OLD.thread_key;
+    END IF;
+    IF is_visible AND (NOT was_visible OR moved) THEN
+        INSERT INTO thread_summaries (thread_key, comment_count, last_comment_at)
+        VALUES (NEW.thread_key, 1, NEW.created_at)
+        ON CONFLICT (thread_key) DO UPDATE
+        SET comment_count = thread_summaries.comment_count + 1,
+            last_comment_at = GREATEST(thread_summaries.last_comment_at, EXCLUDED.last_comment_at);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER thread_summaries_trigger
+    AFTER INSERT OR DELETE OR UPDATE OF status, deleted, thread_key ON comments
+    FOR EACH ROW EXECUTE FUNCTION thread_summaries_update();
+
+-- Триггер уже создан и до конца миграции блокирует запись в comments, поэтому
+-- начальная сводка не расходится с изменениями, сделанными сразу после неё
+INSERT INTO thread_summaries (thread_key, comment_count, last_comment_at)
+SELECT thread_key, count(*), max(created_at)
+FROM comments
+WHERE status = 'approved' AND NOT deleted
+GROUP BY thread_key
+ON CONFLICT (thread_key) DO NOTHING;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS thread_summaries_trigger ON comments;
+DROP FUNCTION IF EXISTS thread_summaries_update();
+DROP INDEX IF EXISTS idx_comments_thread_visible;
+DROP TABLE IF EXISTS thread_summaries;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Субъект SSO-токена автора. Права владельца даёт только он: имя автора аноним
+-- выбирает сам. У анонимных и старых комментариев NULL — ими управляют по токену
+-- управления или модераторы.
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS author_subject TEXT;
+
+-- +goose Down
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS author_subject;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Переигровка доставки больше не обнуляет attempts: номера попыток в журнале растут
+-- сквозь все переигровки, а лимит попыток считается от attempts_before_replay.
+ALTER TABLE webhook_deliveries
+    ADD COLUMN IF NOT EXISTS attempts_before_replay INT NOT NULL DEFAULT 0;
+
+-- Переигранные раньше доставки записали номера 1..N повторно — нумеруем журнал заново по порядку
+WITH numbered AS (
+    SELECT id, row_number() OVER (PARTITION BY delivery_id ORDER BY id)::int AS n
+    FROM webhook_delivery_attempts
+)
+UPDATE webhook_delivery_attempts a
+SET attempt = numbered.n
+FROM numbered
+WHERE a.id = numbered.id AND a.attempt <> numbered.n;
+
+WITH logged AS (
+    SELECT delivery_id, count(*)::int AS n
+    FROM webhook_delivery_attempts
+    GROUP BY delivery_id
+)
+UPDATE webhook_deliveries d
+SET attempts_before_replay = logged.n - d.attempts, attempts = logged.n
+FROM logged
+WHERE d.id = logged.delivery_id AND logged.n > d.attempts;
+
+DROP INDEX IF EXISTS idx_webhook_delivery_attempts_delivery;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, attempt);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_delivery_attempts_delivery;
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, attempt);
+
+ALTER TABLE webhook_deliveries
+    DROP COLUMN IF EXISTS attempts_before_replay;
@@ -1,6 +1,7 @@
 class CommentTree {
     constructor() {
         this.apiUrl = '/comments';
+        this.threadKey = new URLSearchParams(window.location.search).get('thread') || 'default';
         this.currentPage = 1;
         this.limit = 10;
         this.currentSort = 'asc';
@@ -8,11 +9,15 @@ class CommentTree {
         this.currentQuery = '';
         this.replyToId = null;
         this.hasMore = true;
+        this.nextCursor = null;
+        this.prevCursor = null;
+        this.pageCursor = '';
         this.collapsedComments = new Set();
 
         this.initElements();
         this.attachEventListeners();
         this.loadComments();
+        this.subscribeStream();
     }
 
     initElements() {
@@ -64,7 +69,7 @@ class CommentTree {
         // Sort events
         this.sortSelect.addEventListener('change', () => {
             this.currentSort = this.sortSelect.value;
-            this.currentPage = 1;
+            this.resetPage();
             this.loadComments();
         });
 
@@ -95,16 +100,15 @@ class CommentTree {
         element.style.height = Math.max(element.scrollHeight, 150) + 'px';
     }
 
-    async apiCall(url, options = {}) {
+    async apiCall(url, options = {}, extraHeaders = {}) {
         try {
-            const response = await fetch(url, {
-                headers: { 'Content-Type': 'application/json' },
-                ...options
-            });
+            const headers = { 'Content-Type': 'application/json', ...extraHeaders };
+
+            const response = await fetch(url, { headers, ...options });
 
             if (!response.ok) {
                 const error = await response.json();
-                throw new Error(error.error || 'Ошибка сервера');
+                throw new Error(error.detail || error.error || 'Ошибка сервера');
             }
 
             return response.status === 204 ? null : await response.json();
@@ -131,31 +135,67 @@ class CommentTree {
         }
     }
 
+    // Сбрасывает курсорную пагинацию на первую страницу
+    resetPage() {
+        this.currentPage = 1;
+        this.pageCursor = '';
+    }
+
     async loadComments() {
         this.showLoading(true);
-        const offset = (this.currentPage - 1) * this.limit;
 
         let url;
         if (this.isSearchMode) {
-            url = `${this.apiUrl}/search?query=${encodeURIComponent(this.currentQuery)}&limit=${this.limit}&offset=${offset}`;
+            url = `${this.apiUrl}/search?query=${encodeURIComponent(this.currentQuery)}&limit=${this.limit}&format=html`;
         } else {
-            url = `${this.apiUrl}?limit=${this.limit}&offset=${offset}&sort=${this.currentSort}`;
+            url = `${this.apiUrl}?limit=${this.limit}&sort=${this.currentSort}&format=html`;
+        }
+        url += `&thread=${encodeURIComponent(this.threadKey)}`;
+        if (this.pageCursor) {
+            url += `&${this.pageCursor}`;
         }
 
         try {
-            const comments = await this.apiCall(url);
-            this.renderComments(comments || []);
-            this.hasMore = comments && comments.length === this.limit;
+            const page = await this.apiCall(url);
+            let comments = (page && page.items) || [];
+            if (this.isSearchMode) {
+                comments = comments.map(hit => ({ ...hit.comment, snippet: hit.snippet }));
+            }
+            this.renderComments(comments);
+            this.nextCursor = page && page.next_cursor;
+            this.prevCursor = page && page.prev_cursor;
+            this.hasMore = !!this.nextCursor;
             this.updatePagination();
         } catch (error) {
             this.renderComments([]);
             this.hasMore = false;
+            this.nextCursor = null;
+            this.prevCursor = null;
             this.updatePagination();
         } finally {
             this.showLoading(false);
         }
     }
 
+    // Живая лента: при новых, изменённых и удалённых комментариях перечитываем страницу.
+    // EventSource сам переподключается и присылает Last-Event-ID.
+    subscribeStream() {
+        if (!window.EventSource) {
+            return;
+        }
+        const source = new EventSource(`${this.apiUrl}/stream?thread=${encodeURIComponent(this.threadKey)}`);
+        const refresh = () => {
+            // Не сбиваем поиск и не листаем читателя, открывшего ответ
+            if (this.isSearchMode || this.replyModal.style.display === 'block') {
+                return;
+            }
+            clearTimeout(this.streamRefresh);
+            this.streamRefresh = setTimeout(() => this.loadComments(), 300);
+        };
+        ['comment.created', 'comment.edited', 'comment.deleted', 'comment.restored', 'comment.moderated', 'comment.locked', 'stream.reset']
+            .forEach(type => source.addEventListener(type, refresh));
+    }
+
     renderComments(comments) {
         // Clear existing comments but keep loading and no-comments elements
         const existingComments = this.commentsContainer.querySelectorAll('.comment');
@@ -190,8 +230,16 @@ class CommentTree {
                             </button>` :
                 '<span class="collapse-spacer">•</span>'
             }
+                        <span class="comment-votes">
+                            ${!isDeleted ? `<button class="vote-btn" data-value="1">▲</button>` : ''}
+                            <span class="comment-score">${comment.score || 0}</span>
+                            ${!isDeleted ? `<button class="vote-btn" data-value="-1">▼</button>` : ''}
+                        </span>
                         <span class="comment-author">${this.escapeHtml(comment.author)}</span>
+                        ${comment.author_verified ? '<span class="comment-verified" title="Автор вошёл через SSO">✓</span>' : ''}
                         <span class="comment-date">${this.formatDate(comment.created_at)}</span>
+                        ${comment.edited_at ? '<span class="comment-date">(изменено)</span>' : ''}
+                        ${comment.status === 'pending' ? '<span class="moderation-badge">на модерации</span>' : ''}
                         ${totalChildren > 0 ?
                 `<span class="children-count">${totalChildren} ${this.getChildrenText(totalChildren)}</span>` :
                 ''
@@ -202,20 +250,36 @@ class CommentTree {
                             <button class="reply-btn" data-id="${comment.id}" data-author="${this.escapeHtml(comment.author)}">
                                 Ответить
                             </button>
+                            <button class="edit-btn reply-btn" data-id="${comment.id}">
+                                Изменить
+                            </button>
                             <button class="delete-btn" data-id="${comment.id}">
                                 Удалить
                             </button>
                         ` : ''}
                     </div>
                 </div>
-                <div class="comment-content">${this.escapeHtml(content)}</div>
+                ${this.renderContent(comment, content)}
             </div>
         `;
 
         // Attach event listeners for this comment
         if (!isDeleted) {
-            const replyBtn = commentEl.querySelector('.reply-btn');
+            const replyBtn = commentEl.querySelector('.reply-btn:not(.edit-btn)');
             const deleteBtn = commentEl.querySelector('.delete-btn');
+            const editBtn = commentEl.querySelector('.edit-btn');
+
+            commentEl.querySelectorAll(':scope > .comment-wrapper .vote-btn').forEach(btn => {
+                btn.addEventListener('click', () => {
+                    this.voteComment(comment.id, Number(btn.dataset.value));
+                });
+            });
+
+            if (editBtn) {
+                editBtn.addEventListener('click', () => {
+                    this.editComment(comment);
+                });
+            }
 
             if (replyBtn) {
                 replyBtn.addEventListener('click', () => {
@@ -278,6 +342,18 @@ class CommentTree {
         this.loadComments();
     }
 
+    // content_html уже очищен сервером; сниппеты поиска и удалённые комментарии остаются текстом
+    renderContent(comment, content) {
+        if (comment.snippet) {
+            // Текст сниппета экранирует сервер, разметка в нём — только маркеры подсветки
+            return `<div class="comment-content">${comment.snippet}</div>`;
+        }
+        if (!comment.deleted && comment.content_html) {
+            return `<div class="comment-content rendered">${comment.content_html}</div>`;
+        }
+        return `<div class="comment-content">${this.escapeHtml(content)}</div>`;
+    }
+
     async createComment(parentId = null) {
         const author = parentId ? this.replyAuthorInput.value.trim() : this.authorInput.value.trim();
         const content = parentId ? this.replyContentInput.value.trim() : this.contentInput.value.trim();
@@ -290,14 +366,26 @@ class CommentTree {
         const payload = { author, content };
         if (parentId) {
             payload.parent_id = parentId;
+        } else {
+            payload.thread_key = this.threadKey;
         }
 
         try {
-            await this.apiCall(this.apiUrl, {
+            const created = await this.apiCall(this.apiUrl, {
                 method: 'POST',
                 body: JSON.stringify(payload)
             });
 
+            if (created && created.management_token) {
+                this.saveManagementToken(created.id, created.management_token);
+            }
+
+            if (created && created.status === 'pending') {
+                this.showError('Комментарий отправлен на модерацию');
+            } else if (created && created.status === 'rejected') {
+                this.showError('Комментарий отклонён спам-фильтром');
+            }
+
             if (parentId) {
                 this.closeReplyModal();
             } else {
@@ -311,11 +399,58 @@ class CommentTree {
         }
     }
 
+    async editComment(comment) {
+        const content = prompt('Новый текст комментария', comment.content);
+        if (content === null || !content.trim() || content.trim() === comment.content) return;
+
+        try {
+            await this.apiCall(`${this.apiUrl}/${comment.id}`, {
+                method: 'PUT',
+                body: JSON.stringify({ editor: comment.author, content: content.trim() })
+            }, this.managementHeaders(comment.id));
+            this.loadComments();
+        } catch (error) {
+            // Error already handled in apiCall
+        }
+    }
+
+    // Токены управления своими комментариями хранятся в браузере: сервер выдаёт их один раз
+    loadManagementTokens() {
+        try {
+            return JSON.parse(localStorage.getItem('commentTreeTokens')) || {};
+        } catch {
+            return {};
+        }
+    }
+
+    saveManagementToken(id, token) {
+        const tokens = this.loadManagementTokens();
+        tokens[id] = token;
+        localStorage.setItem('commentTreeTokens', JSON.stringify(tokens));
+    }
+
+    managementHeaders(id) {
+        const token = this.loadManagementTokens()[id];
+        return token ? { 'X-Management-Token': token } : {};
+    }
+
+    async voteComment(id, value) {
+        try {
+            await this.apiCall(`${this.apiUrl}/${id}/vote`, {
+                method: 'POST',
+                body: JSON.stringify({ value })
+            });
+            this.loadComments();
+        } catch (error) {
+            // Error already handled in apiCall
+        }
+    }
+
     async deleteComment(id) {
         if (!confirm('Удалить комментарий?')) return;
 
         try {
-            await this.apiCall(`${this.apiUrl}/${id}`, { method: 'DELETE' });
+            await this.apiCall(`${this.apiUrl}/${id}`, { method: 'DELETE' }, this.managementHeaders(id));
             this.loadComments();
         } catch (error) {
             // Error already handled in apiCall
@@ -331,7 +466,7 @@ class CommentTree {
 
         this.isSearchMode = true;
         this.currentQuery = query;
-        this.currentPage = 1;
+        this.resetPage();
         this.collapsedComments.clear();
         this.loadComments();
     }
@@ -340,7 +475,7 @@ class CommentTree {
         this.searchInput.value = '';
         this.isSearchMode = false;
         this.currentQuery = '';
-        this.currentPage = 1;
+        this.resetPage();
         this.collapsedComments.clear();
         this.loadComments();
     }
@@ -368,20 +503,22 @@ class CommentTree {
 
     updatePagination() {
         this.pageInfo.textContent = `Страница ${this.currentPage}`;
-        this.prevBtn.disabled = this.currentPage <= 1;
+        this.prevBtn.disabled = this.currentPage <= 1 || !this.prevCursor;
         this.nextBtn.disabled = !this.hasMore;
     }
 
     prevPage() {
-        if (this.currentPage > 1) {
+        if (this.currentPage > 1 && this.prevCursor) {
             this.currentPage--;
+            this.pageCursor = this.currentPage === 1 ? '' : `before=${encodeURIComponent(this.prevCursor)}`;
             this.loadComments();
         }
     }
 
     nextPage() {
-        if (this.hasMore) {
+        if (this.hasMore && this.nextCursor) {
             this.currentPage++;
+            this.pageCursor = `after=${encodeURIComponent(this.nextCursor)}`;
             this.loadComments();
         }
     }
@@ -397,6 +534,7 @@ class CommentTree {
         });
     }
 
+    // Сниппет приходит с маркерами <mark>…</mark> вокруг совпадений, остальное экранируем
     escapeHtml(text) {
         const div = document.createElement('div');
         div.textContent = text;